@@ -0,0 +1,109 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/infra"
+	"github.com/dougefr/go-clean-arch/interface/gateway"
+)
+
+const usage = `usage: migrate [flags] <command>
+
+commands:
+  up        applies every pending migration
+  down [n]  reverts the last n applied migrations (default 1)
+  status    lists the migrations and when they were applied
+
+the flags are listed by migrate -h
+`
+
+// migrate entrypoint
+func main() {
+	cfg, args, err := infra.LoadConfig("migrate", os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+	if cfg.Database.Dialect == infra.DialectMemory {
+		fmt.Println("the memory dialect has no schema to migrate")
+		os.Exit(2)
+	}
+
+	db, err := infra.NewDatabase(cfg.Database)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	logger, err := infra.NewLogrus(cfg.Log.Level, cfg.Log.Format)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+
+	emailRules := entity.EmailRules{CaseSensitiveLocalPart: cfg.Email.CaseSensitiveLocalPart}
+	migrator := gateway.NewMigrator(db, logger, emailRules)
+	ctx := context.Background()
+
+	if len(args) == 0 {
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if _, err = fmt.Sscan(args[1], &steps); err != nil || steps < 1 {
+				fmt.Print(usage)
+				os.Exit(2)
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case "status":
+		err = status(ctx, migrator)
+	default:
+		fmt.Print(usage)
+		os.Exit(2)
+	}
+
+	if closeErr := db.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+}
+
+// prints every known migration and when it was applied
+func status(ctx context.Context, migrator gateway.Migrator) error {
+	migrations, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		appliedAt := "pending"
+		if m.AppliedAt != nil {
+			appliedAt = m.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d %-40s %s\n", m.Version, m.Name, appliedAt)
+	}
+
+	return nil
+}
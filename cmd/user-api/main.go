@@ -6,54 +6,138 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"net"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/dougefr/go-clean-arch/entity"
 	"github.com/dougefr/go-clean-arch/infra"
 	"github.com/dougefr/go-clean-arch/interface/gateway"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
 	"github.com/dougefr/go-clean-arch/interface/restctrl"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
 	"github.com/dougefr/go-clean-arch/usecase/interactor"
-	"github.com/gofiber/fiber"
 )
 
+// userStorage is where the users are kept
+type userStorage struct {
+	gateway  igateway.User
+	session  iinfra.Session
+	checkers []iinfra.HealthChecker // the readiness of the storage
+	close    func() error           // releases the storage once nothing uses it anymore
+}
+
 // user-api entrypoint
 func main() {
-	db, err := infra.NewSQLite3()
+	cfg, args, err := infra.LoadConfig("user-api", os.Args[1:], os.LookupEnv)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err == nil && len(args) > 0 {
+		err = fmt.Errorf("unexpected arguments: %v", args)
+	}
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(2)
+	}
+
+	logger, err := infra.NewLogrus(cfg.Log.Level, cfg.Log.Format)
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
-	logger, err := infra.NewLogrus("debug")
+	logger.Info(context.Background(), "config loaded", cfg.Redacted().LogAttrs())
+
+	exporter, closeExporter, err := infra.NewSpanExporter(cfg.Trace)
+	if err != nil {
+		fmt.Println(err.Error())
+		os.Exit(1)
+	}
+	tracer := infra.NewTracer("user-api", exporter, logger)
+
+	metrics := infra.NewPrometheus()
+	emailRules := entity.EmailRules{CaseSensitiveLocalPart: cfg.Email.CaseSensitiveLocalPart}
+	users, err := newUserStorage(cfg.Database, emailRules, logger, metrics, tracer)
 	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 
-	userRepo := gateway.NewUserGateway(db, logger)
-	ucCreateUser := interactor.NewCreateUser(userRepo)
-	ucSearchUser := interactor.NewSearchUser(userRepo)
-	userController := restctrl.NewUser(ucCreateUser, ucSearchUser, db, logger)
+	userController := newUserController(users.gateway, users.session, emailRules, logger, metrics, tracer)
+	srv := newServer(cfg.HTTP, userController, restctrl.NewHealth(users.checkers, logger), metrics, tracer, logger)
 
-	app := fiber.New()
-	app.Post("/user", do(userController.Create))
-	app.Get("/user", do(userController.Search))
+	ln, err := net.Listen("tcp", cfg.HTTP.Addr)
+	if err == nil {
+		// SIGTERM is sent by the orchestrators before killing the process, SIGINT by the terminal
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		logger.Info(ctx, fmt.Sprintf("listening to %s...", ln.Addr()))
+		err = srv.run(ctx, ln)
+		stop()
+	}
 
-	logger.Info(context.Background(), "listening to port 8080...")
-	if err = app.Listen(8080); err != nil {
+	// nothing uses the storage anymore, the requests in flight were drained or cancelled
+	if closeErr := users.close(); closeErr != nil {
+		logger.Error(context.Background(), fmt.Sprintf("error when closing the storage: %v", closeErr))
+	}
+	// and every span has ended
+	if closeErr := closeExporter(); closeErr != nil {
+		logger.Error(context.Background(), fmt.Sprintf("error when closing the trace exporter: %v", closeErr))
+	}
+	if flusher, ok := logger.(iinfra.LogFlusher); ok {
+		flusher.Flush()
+	}
+
+	if err != nil {
 		fmt.Println(err.Error())
 		os.Exit(1)
 	}
 }
 
-// translates the rest ctrl results to fiber standards
-func do(fn func(restctrl.RestRequest) restctrl.RestResponse) func(ctx *fiber.Ctx) {
-	return func(ctx *fiber.Ctx) {
-		var req restctrl.RestRequest
-		req.Body = ctx.Fasthttp.PostBody()
-		req.GetQueryParam = func(key string) string {
-			return ctx.Query(key)
-		}
-		resp := fn(req) // execute the controller function
-		ctx.Status(resp.StatusCode).SendBytes(resp.Body)
+// newUserController wires the use cases of the users to their controller
+func newUserController(userRepo igateway.User, session iinfra.Session, emailRules entity.EmailRules,
+	logger iinfra.LogProvider, metrics iinfra.MetricsProvider, tracer iinfra.Tracer) restctrl.User {
+	ucCreateUser := interactor.NewCreateUser(userRepo, interactor.DefaultNameRules, emailRules)
+	ucSearchUser := interactor.NewSearchUser(userRepo, emailRules)
+	ucUpdateUser := interactor.NewUpdateUser(userRepo, interactor.DefaultNameRules, emailRules)
+	ucDeleteUser := interactor.NewDeleteUser(userRepo)
+	ucRestoreUser := interactor.NewRestoreUser(userRepo)
+	ucGetUser := interactor.NewGetUser(userRepo)
+	return restctrl.NewUser(ucCreateUser, ucSearchUser, ucUpdateUser, ucDeleteUser, ucRestoreUser,
+		ucGetUser, infra.NewUnitOfWork(session), logger, metrics, tracer)
+}
+
+// newUserStorage builds the user gateway over the SQL database of the dialect, or over a memory store
+// when the dialect is "memory", which loses every data when the server stops
+func newUserStorage(cfg infra.DatabaseConfig, emailRules entity.EmailRules, logger iinfra.LogProvider,
+	metrics *infra.Prometheus, tracer iinfra.Tracer) (s userStorage, err error) {
+	if cfg.Dialect == infra.DialectMemory {
+		store := gateway.NewMemoryStore()
+		s.gateway = gateway.NewMemoryUserGateway(store)
+		s.session = store
+		s.close = func() error { return nil }
+		return
+	}
+
+	db, err := infra.NewDatabase(cfg)
+	if err != nil {
+		return
 	}
+
+	// the schema must be up to date before serving any request
+	migrator := gateway.NewMigrator(db, logger, emailRules)
+	if err = migrator.Up(context.Background()); err != nil {
+		_ = db.Close()
+		return
+	}
+
+	s.gateway = gateway.NewUserGateway(db, logger, metrics, tracer)
+	metrics.OnCollect(infra.DatabasePoolCollector(db, metrics))
+	s.session = db
+	s.checkers = []iinfra.HealthChecker{infra.NewDatabaseChecker(db), gateway.NewMigrationChecker(migrator)}
+	s.close = db.Close
+	return
 }
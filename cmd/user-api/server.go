@@ -0,0 +1,414 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/infra"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/interface/restctrl"
+	"github.com/gofiber/fiber"
+)
+
+// shutdownFlushTimeout is the time the responses of the requests cancelled at the shutdown deadline have
+// to be written, the connections still open after it are closed
+const shutdownFlushTimeout = time.Second
+
+type (
+	// server serves the user-api. Its shutdown stops accepting connections and drains the requests in
+	// flight, the ones still running at the deadline are cancelled, so their transactions are rolled back.
+	// It returns once the responses were written and the connections closed
+	server struct {
+		app     *fiber.App
+		cfg     infra.HTTPConfig
+		logger  iinfra.LogProvider
+		metrics *infra.Prometheus
+		tracer  iinfra.Tracer
+		// base is the parent of the request contexts, it's cancelled at the shutdown deadline
+		base     context.Context
+		cancel   context.CancelFunc
+		requests requests
+		conns    connTracker
+	}
+
+	// requests counts the requests in flight, once draining it refuses the new ones
+	requests struct {
+		mu       sync.Mutex
+		n        int
+		draining bool
+		drained  chan struct{} // closed when draining and there is no request in flight
+	}
+
+	// connTracker is the listener of the server, it tracks the connections so the idle keep-alive ones
+	// can be closed at the shutdown, which would wait for them otherwise
+	connTracker struct {
+		net.Listener
+		mu      sync.Mutex
+		conns   map[*trackedConn]struct{}
+		closing bool // the new connections are closed as soon as accepted
+	}
+
+	// trackedConn is busy from the start of a request until the server reads from it again, which is
+	// once the response was written
+	trackedConn struct {
+		net.Conn
+		tracker *connTracker
+		busy    int32
+		peeked  []byte // read while watching the connection, given back by the next Read
+	}
+)
+
+// newServer routes the controllers, and the metrics to /metrics
+func newServer(cfg infra.HTTPConfig, userController restctrl.User, healthController restctrl.Health,
+	metrics *infra.Prometheus, tracer iinfra.Tracer, logger iinfra.LogProvider) *server {
+	s := &server{
+		cfg:     cfg,
+		logger:  logger,
+		metrics: metrics,
+		tracer:  tracer,
+		app: fiber.New(&fiber.Settings{
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+		}),
+	}
+	s.base, s.cancel = context.WithCancel(context.Background())
+
+	s.app.Post("/user", s.do("/user", s.requireTx(userController.Create)))
+	s.app.Get("/user", s.do("/user", s.requireTx(userController.Search)))
+	s.app.Get("/user/:id", s.do("/user/:id", s.requireTx(userController.Get)))
+	s.app.Put("/user/:id", s.do("/user/:id", s.requireTx(userController.Update)))
+	s.app.Patch("/user/:id", s.do("/user/:id", s.requireTx(userController.Patch)))
+	s.app.Delete("/user/:id", s.do("/user/:id", s.requireTx(userController.Delete)))
+	s.app.Post("/user/:id/restore", s.do("/user/:id/restore", s.requireTx(userController.Restore)))
+
+	// the probes of the orchestrators
+	s.app.Get("/healthz", s.do("/healthz", healthController.Live))
+	s.app.Get("/readyz", s.do("/readyz", healthController.Ready))
+	s.app.Get("/metrics", func(ctx *fiber.Ctx) {
+		ctx.Set("Content-Type", infra.PrometheusContentType)
+		if _, err := metrics.WriteTo(ctx.Fasthttp); err != nil {
+			logger.Error(context.Background(), fmt.Sprintf("error when writing the metrics: %v", err))
+		}
+	})
+
+	return s
+}
+
+// run serves the connections of ln until ctx is done, then shuts the server down
+func (s *server) run(ctx context.Context, ln net.Listener) error {
+	served := make(chan error, 1)
+	go func() {
+		s.conns.Listener = ln
+		served <- s.app.Serve(&s.conns)
+	}()
+
+	select {
+	case err := <-served:
+		return err
+	case <-ctx.Done():
+	}
+
+	s.shutdown()
+	return nil
+}
+
+// shutdown returns once there is no request in flight and their responses were written, the requests
+// still running after the shutdown timeout are cancelled
+func (s *server) shutdown() {
+	startTime := time.Now()
+	ctx := context.Background()
+	s.logger.Info(ctx, "shutting down...")
+
+	// the requests in flight answer with Connection: close, so fasthttp closes their connections once
+	// the responses are written, and the other connections have no request to wait for
+	drained := s.requests.drain()
+	s.conns.closeIdle()
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		if err := s.app.Shutdown(); err != nil {
+			s.logger.Error(ctx, fmt.Sprintf("error when shutting down the server: %v", err))
+		}
+	}()
+
+	timer := time.NewTimer(s.cfg.ShutdownTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+	case <-timer.C:
+		s.logger.Warn(ctx, "shutdown timeout reached, cancelling the requests in flight")
+		s.cancel()
+		<-drained
+
+		flush := time.NewTimer(shutdownFlushTimeout)
+		defer flush.Stop()
+		select {
+		case <-stopped:
+		case <-flush.C:
+			s.logger.Warn(ctx, "closing the connections whose responses weren't written")
+			s.conns.closeAll()
+			<-stopped
+		}
+	}
+
+	s.logger.Info(ctx, "server stopped", iinfra.LogAttrs{"duration": time.Since(startTime).String()})
+}
+
+// translates the rest ctrl results to fiber standards, the requests are measured by their route, which
+// is the path with the params names. Each request is the span of the controller, which continues the
+// trace of the traceparent header if any
+func (s *server) do(route string, fn func(restctrl.RestRequest) restctrl.RestResponse) func(ctx *fiber.Ctx) {
+	return func(ctx *fiber.Ctx) {
+		defer s.observe(ctx, route, time.Now())
+
+		if c, ok := ctx.Fasthttp.Conn().(*trackedConn); ok {
+			c.setBusy()
+		}
+		if !s.requests.begin() {
+			refuse(ctx)
+			return
+		}
+		defer s.requests.end()
+
+		// the work is bounded by the timeout, by the shutdown deadline and by the client, that can go
+		// away before the response. The fasthttp context isn't the parent because it's done as soon as
+		// the shutdown starts, which would cut off the requests that can still finish
+		reqCtx, cancel := context.WithTimeout(s.base, s.cfg.RequestTimeout)
+		defer cancel()
+		if c, ok := ctx.Fasthttp.Conn().(*trackedConn); ok {
+			defer c.watch(cancel)()
+		}
+
+		reqCtx = s.tracer.Extract(reqCtx, ctx.Get("traceparent"))
+		reqCtx, span := s.tracer.Start(reqCtx, ctx.Method()+" "+route, iinfra.SpanAttrs{
+			"http.method": ctx.Method(),
+			"http.route":  route,
+		})
+		defer span.End()
+		reqCtx = iinfra.WithLogAttrs(reqCtx, iinfra.LogAttrs{"trace-id": span.TraceID()})
+
+		var req restctrl.RestRequest
+		req.Context = reqCtx
+		req.Body = ctx.Fasthttp.PostBody()
+		req.GetQueryParam = func(key string) string {
+			return ctx.Query(key)
+		}
+		req.GetQueryParamKeys = func() (keys []string) {
+			ctx.Fasthttp.QueryArgs().VisitAll(func(key, _ []byte) {
+				keys = append(keys, string(key))
+			})
+			return
+		}
+		req.GetPathParam = func(key string) string {
+			return ctx.Params(key)
+		}
+		resp := fn(req) // execute the controller function
+
+		span.SetAttrs(iinfra.SpanAttrs{"http.status_code": resp.StatusCode})
+		if resp.StatusCode >= http.StatusInternalServerError {
+			span.RecordError(errors.New(http.StatusText(resp.StatusCode)))
+		}
+		if resp.StatusCode >= http.StatusInternalServerError && s.base.Err() != nil {
+			// cancelled by the shutdown deadline, it didn't fail by itself
+			refuse(ctx)
+			return
+		}
+		if s.requests.isDraining() {
+			ctx.Fasthttp.SetConnectionClose()
+		}
+		if resp.ContentType != "" {
+			ctx.Set("Content-Type", resp.ContentType)
+		}
+		ctx.Status(resp.StatusCode).SendBytes(resp.Body)
+	}
+}
+
+// requireTx makes the gateway calls of fn fail outside of a transaction when the config asks for it. The
+// probes aren't wrapped, their checks run on their own
+func (s *server) requireTx(fn func(restctrl.RestRequest) restctrl.RestResponse) func(
+	restctrl.RestRequest) restctrl.RestResponse {
+	if !s.cfg.RequireTx {
+		return fn
+	}
+
+	return func(req restctrl.RestRequest) restctrl.RestResponse {
+		req.Context = iinfra.WithTxRequired(req.Context)
+		return fn(req)
+	}
+}
+
+// refuse answers that the server is shutting down, the client should retry on another instance
+func refuse(ctx *fiber.Ctx) {
+	ctx.Fasthttp.SetConnectionClose()
+	ctx.Status(fiber.StatusServiceUnavailable)
+}
+
+// observe counts the request and records its latency, by route and status
+func (s *server) observe(ctx *fiber.Ctx, route string, startTime time.Time) {
+	labels := iinfra.MetricLabels{
+		"method": ctx.Method(),
+		"route":  route,
+		"status": strconv.Itoa(ctx.Fasthttp.Response.StatusCode()),
+	}
+	s.metrics.Counter("http_requests_total", labels, 1)
+	s.metrics.Histogram("http_request_duration_seconds", labels, time.Since(startTime).Seconds())
+}
+
+// begin counts a new request, it returns false when draining
+func (r *requests) begin() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.draining {
+		return false
+	}
+	r.n++
+	return true
+}
+
+// end ...
+func (r *requests) end() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.n--
+	if r.draining && r.n == 0 {
+		close(r.drained)
+	}
+}
+
+// isDraining ...
+func (r *requests) isDraining() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.draining
+}
+
+// drain refuses the new requests, the channel is closed when the ones in flight end
+func (r *requests) drain() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.draining {
+		r.draining = true
+		r.drained = make(chan struct{})
+		if r.n == 0 {
+			close(r.drained)
+		}
+	}
+	return r.drained
+}
+
+// Accept tracks the connection, once closing it's closed right away
+func (t *connTracker) Accept() (net.Conn, error) {
+	c, err := t.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &trackedConn{Conn: c, tracker: t}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closing {
+		_ = c.Close()
+		return tc, nil
+	}
+	if t.conns == nil {
+		t.conns = make(map[*trackedConn]struct{})
+	}
+	t.conns[tc] = struct{}{}
+	return tc, nil
+}
+
+// closeIdle closes the connections with no request in flight, and the new ones from now on. The requests
+// whose reading is cut off would be refused anyway
+func (t *connTracker) closeIdle() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closing = true
+	for c := range t.conns {
+		if atomic.LoadInt32(&c.busy) == 0 {
+			_ = c.Conn.Close()
+		}
+	}
+}
+
+// closeAll ...
+func (t *connTracker) closeAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.closing = true
+	for c := range t.conns {
+		_ = c.Conn.Close()
+	}
+}
+
+// setBusy is called at the start of a request, before it's counted by requests.begin, so closeIdle never
+// closes a connection whose request was accepted
+func (c *trackedConn) setBusy() {
+	atomic.StoreInt32(&c.busy, 1)
+}
+
+// Read is only called by fasthttp between the requests, the response of the previous one was written
+func (c *trackedConn) Read(b []byte) (int, error) {
+	atomic.StoreInt32(&c.busy, 0)
+	if len(c.peeked) > 0 {
+		n := copy(b, c.peeked)
+		c.peeked = c.peeked[n:]
+		return n, nil
+	}
+	return c.Conn.Read(b)
+}
+
+// watch cancels the request being handled when the client closes the connection, fasthttp doesn't read
+// from it meanwhile, so it can't tell. The returned function stops watching, it must be called before
+// the response is written. A byte of a pipelined request stops the watching too, since the client is
+// still there, and is kept for the next Read
+func (c *trackedConn) watch(cancel context.CancelFunc) (stop func()) {
+	// the deadline of the request read would end the watching, fasthttp sets a new one before the next
+	// read. It's cleared here, so stop can't be run before it
+	_ = c.Conn.SetReadDeadline(time.Time{})
+
+	var stopping int32
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		b := make([]byte, 1)
+		n, err := c.Conn.Read(b)
+		c.peeked = append(c.peeked, b[:n]...)
+		if err != nil && atomic.LoadInt32(&stopping) == 0 {
+			cancel()
+		}
+	}()
+
+	return func() {
+		atomic.StoreInt32(&stopping, 1)
+		_ = c.Conn.SetReadDeadline(time.Now())
+		<-done
+		_ = c.Conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// Close ...
+func (c *trackedConn) Close() error {
+	c.tracker.mu.Lock()
+	delete(c.tracker.conns, c)
+	c.tracker.mu.Unlock()
+	return c.Conn.Close()
+}
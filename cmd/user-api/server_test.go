@@ -0,0 +1,334 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/infra"
+	"github.com/dougefr/go-clean-arch/interface/gateway"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/interface/restctrl"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowSession holds every transaction open for a while after beginning it, or until its context is done.
+// The beginning of the first one is sent to began
+type slowSession struct {
+	iinfra.Database
+	delay time.Duration
+	began chan struct{}
+}
+
+func (s slowSession) BeginTx(ctx context.Context, opts *sql.TxOptions) (iinfra.Tx, error) {
+	tx, err := s.Database.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.began <- struct{}{}:
+	default:
+	}
+	select {
+	case <-time.After(s.delay):
+	case <-ctx.Done():
+	}
+	return tx, nil
+}
+
+func TestServer(t *testing.T) {
+	const body = `{"name": "fake name", "email": "fake@email.com"}`
+
+	logger, err := infra.NewLogrus("panic", infra.LogFormatJSON)
+	require.NoError(t, err)
+
+	// start serves the user-api over a new database, whose transactions take the delay to finish. The
+	// spans are exported to the exporter, if any
+	start := func(t *testing.T, delay, shutdownTimeout time.Duration, exporter infra.SpanExporter) (url string,
+		users igateway.User, began chan struct{}, stop context.CancelFunc, stopped chan error) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+		require.NoError(t, gateway.NewMigrator(db, logger, entity.EmailRules{}).Up(context.Background()))
+
+		began = make(chan struct{}, 1)
+		session := slowSession{Database: db, delay: delay, began: began}
+		cfg := infra.DefaultConfig().HTTP
+		cfg.ShutdownTimeout = shutdownTimeout
+		cfg.RequireTx = true // the gateway calls made outside of a transaction fail the tests
+		metrics := infra.NewPrometheus()
+		tracer := infra.NewTracer("user-api", exporter, logger)
+		users = gateway.NewUserGateway(db, logger, metrics, tracer)
+		srv := newServer(cfg, newUserController(users, session, entity.EmailRules{}, logger, metrics, tracer),
+			restctrl.NewHealth(nil, logger), metrics, tracer, logger)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+
+		var ctx context.Context
+		ctx, stop = context.WithCancel(context.Background())
+		stopped = make(chan error, 1)
+		go func() {
+			stopped <- srv.run(ctx, ln)
+		}()
+		return "http://" + ln.Addr().String(), users, began, stop, stopped
+	}
+
+	// post creates the user in background, the status is sent once it's done
+	post := func(url string) chan int {
+		status := make(chan int, 1)
+		go func() {
+			resp, err := http.Post(url+"/user", "application/json", strings.NewReader(body))
+			if err != nil {
+				status <- 0
+				return
+			}
+			resp.Body.Close()
+			status <- resp.StatusCode
+		}()
+		return status
+	}
+
+	t.Run("should finish the requests in flight before stopping", func(t *testing.T) {
+		url, users, began, stop, stopped := start(t, 500*time.Millisecond, 10*time.Second, nil)
+		status := post(url)
+
+		<-began // the transaction of the request is open
+		stop()
+
+		assert.Equal(t, http.StatusCreated, <-status)
+		assert.NoError(t, <-stopped)
+
+		// the transaction was committed
+		_, err := users.FindByEmail(context.Background(), "fake@email.com")
+		assert.NoError(t, err)
+
+		// and no new connection is accepted
+		_, err = http.Get(url + "/user")
+		assert.Error(t, err)
+	})
+
+	t.Run("should roll back the requests still in flight at the shutdown timeout", func(t *testing.T) {
+		url, users, began, stop, stopped := start(t, time.Minute, 200*time.Millisecond, nil)
+		status := post(url)
+
+		<-began
+		startTime := time.Now()
+		stop()
+
+		// the client is told to retry, as when the request is refused
+		assert.Equal(t, http.StatusServiceUnavailable, <-status)
+		assert.NoError(t, <-stopped)
+		assert.Less(t, int64(time.Since(startTime)), int64(10*time.Second))
+
+		_, err := users.FindByEmail(context.Background(), "fake@email.com")
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+	})
+
+	t.Run("should cancel the request when the client goes away", func(t *testing.T) {
+		url, users, began, stop, stopped := start(t, time.Minute, 10*time.Second, nil)
+
+		conn, err := net.Dial("tcp", strings.TrimPrefix(url, "http://"))
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("POST /user HTTP/1.1\r\nHost: test\r\nContent-Type: application/json\r\n" +
+			"Content-Length: " + strconv.Itoa(len(body)) + "\r\n\r\n" + body))
+		require.NoError(t, err)
+
+		<-began // the transaction of the request is open
+		require.NoError(t, conn.Close())
+
+		// the request is done long before the shutdown deadline
+		startTime := time.Now()
+		stop()
+		assert.NoError(t, <-stopped)
+		assert.Less(t, int64(time.Since(startTime)), int64(5*time.Second))
+
+		// and its transaction was rolled back
+		_, err = users.FindByEmail(context.Background(), "fake@email.com")
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+	})
+
+	t.Run("should make the gateway calls of every user request inside a transaction", func(t *testing.T) {
+		url, _, _, stop, stopped := start(t, 0, 10*time.Second, nil)
+		defer func() {
+			stop()
+			<-stopped
+		}()
+		require.Equal(t, http.StatusCreated, <-post(url))
+
+		for _, test := range []struct {
+			method, path, body string
+			status             int
+		}{
+			{http.MethodGet, "/user?name=fake", "", http.StatusOK},
+			{http.MethodGet, "/user/1", "", http.StatusOK},
+			{http.MethodPut, "/user/1", `{"name": "new name", "email": "new@email.com"}`, http.StatusOK},
+			{http.MethodPatch, "/user/1", `{"name": "newer name"}`, http.StatusOK},
+			{http.MethodDelete, "/user/1", "", http.StatusNoContent},
+			{http.MethodPost, "/user/1/restore", "", http.StatusOK},
+		} {
+			req, err := http.NewRequest(test.method, url+test.path, strings.NewReader(test.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := http.DefaultClient.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, test.status, resp.StatusCode, test.method+" "+test.path)
+		}
+	})
+
+	t.Run("should not wait for the idle keep-alive connections", func(t *testing.T) {
+		url, _, _, stop, stopped := start(t, 0, 10*time.Second, nil)
+		client := &http.Client{Transport: &http.Transport{}}
+		defer client.CloseIdleConnections()
+
+		resp, err := client.Post(url+"/user", "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// the connection is kept open by the client
+		startTime := time.Now()
+		stop()
+		assert.NoError(t, <-stopped)
+		assert.Less(t, int64(time.Since(startTime)), int64(5*time.Second))
+	})
+
+	t.Run("should expose the metrics of the requests", func(t *testing.T) {
+		url, _, _, stop, stopped := start(t, 0, 10*time.Second, nil)
+		defer func() {
+			stop()
+			<-stopped
+		}()
+		require.Equal(t, http.StatusCreated, <-post(url))
+
+		resp, err := http.Get(url + "/metrics")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		metrics, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		assert.Equal(t, infra.PrometheusContentType, resp.Header.Get("Content-Type"))
+		assert.Contains(t, string(metrics), `http_requests_total{method="POST",route="/user",status="201"} 1`)
+		assert.Contains(t, string(metrics), `interactor_executions_total{interactor="create_user",outcome="ok"} 1`)
+		assert.Contains(t, string(metrics), `gateway_query_duration_seconds_count{method="create",outcome="ok"} 1`)
+	})
+
+	t.Run("should trace the request from the controller to the statements", func(t *testing.T) {
+		const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+
+		var buf bytes.Buffer
+		url, _, _, stop, stopped := start(t, 0, 10*time.Second, infra.NewWriterExporter(&buf))
+
+		req, err := http.NewRequest(http.MethodPost, url+"/user", strings.NewReader(body))
+		require.NoError(t, err)
+		req.Header.Set("traceparent", "00-"+traceID+"-"+parentID+"-01")
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// the spans are all exported once the server is stopped
+		stop()
+		require.NoError(t, <-stopped)
+
+		spans := make(map[string]infra.SpanData)
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var span infra.SpanData
+			require.NoError(t, dec.Decode(&span))
+			assert.Equal(t, traceID, span.TraceID, span.Name)
+			spans[span.Name] = span
+		}
+
+		// each span is a child of the one of the previous layer
+		var parent infra.SpanData
+		parent.SpanID = parentID
+		for _, name := range []string{"POST /user", "interactor.create_user.Execute", "gateway.user.create",
+			"db.exec"} {
+			span, ok := spans[name]
+			require.True(t, ok, name)
+			assert.Equal(t, parent.SpanID, span.ParentSpanID, name)
+			parent = span
+		}
+		assert.Equal(t, float64(http.StatusCreated), spans["POST /user"].Attributes["http.status_code"])
+		assert.Contains(t, spans["db.exec"].Attributes["db.statement"], "INSERT INTO users")
+	})
+}
+
+func TestRequests(t *testing.T) {
+	t.Run("should be drained when the requests in flight end", func(t *testing.T) {
+		var r requests
+		require.True(t, r.begin())
+
+		drained := r.drain()
+		select {
+		case <-drained:
+			t.Fatal("drained with a request in flight")
+		default:
+		}
+
+		r.end()
+		<-drained
+	})
+
+	t.Run("should refuse the new requests when draining", func(t *testing.T) {
+		var r requests
+		<-r.drain()
+		assert.False(t, r.begin())
+	})
+}
+
+func TestTrackedConn(t *testing.T) {
+	t.Run("should keep the pipelined bytes read while watching", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		c := &trackedConn{Conn: server, tracker: &connTracker{}}
+
+		cancelled := false
+		stop := c.watch(func() { cancelled = true })
+		_, err := client.Write([]byte("G")) // net.Pipe writes wait for the reads
+		require.NoError(t, err)
+		stop()
+
+		go func() {
+			_, _ = client.Write([]byte("ET"))
+		}()
+		b := make([]byte, 2)
+		n, err := c.Read(b)
+		require.NoError(t, err)
+		assert.Equal(t, "G", string(b[:n]))
+		n, err = c.Read(b)
+		require.NoError(t, err)
+		assert.Equal(t, "ET", string(b[:n]))
+		assert.False(t, cancelled)
+	})
+
+	t.Run("should not cancel when the watching stops", func(t *testing.T) {
+		server, client := net.Pipe()
+		defer client.Close()
+		c := &trackedConn{Conn: server, tracker: &connTracker{}}
+
+		cancelled := false
+		c.watch(func() { cancelled = true })()
+		assert.False(t, cancelled)
+	})
+}
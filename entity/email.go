@@ -0,0 +1,195 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package entity
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/text/unicode/norm"
+)
+
+// limits of RFC 5321 and RFC 1035, longer addresses can't be delivered
+const (
+	emailMaxLength      = 254
+	emailLocalMaxLength = 64
+	domainMaxLength     = 253
+	labelMaxLength      = 63
+)
+
+// special chars that the atoms of RFC 5322 can have
+const atextSpecials = "!#$%&'*+-/=?^_`{|}~"
+
+// idnaContextRunes are the runes that IDNA 2008 allows by their context although they aren't letters,
+// marks or digits, ex: the catalan middle dot of l·l. The joiners are checked by idna.Lookup, the
+// others are left to the registries
+var idnaContextRunes = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00b7, Hi: 0x00b7, Stride: 1}, // middle dot
+		{Lo: 0x0375, Hi: 0x0375, Stride: 1}, // greek lower numeral sign
+		{Lo: 0x05f3, Hi: 0x05f4, Stride: 1}, // hebrew geresh and gershayim
+		{Lo: 0x200c, Hi: 0x200d, Stride: 1}, // zero width non-joiner and joiner
+		{Lo: 0x30fb, Hi: 0x30fb, Stride: 1}, // katakana middle dot
+	},
+}
+
+// EmailRules are the choices of the canonical form of the addresses, the zero value is the usual one
+type EmailRules struct {
+	// CaseSensitiveLocalPart keeps the case of the local part. RFC 5321 lets the mail servers tell
+	// Foo@ from foo@, though nearly none does, so it's lower-cased by default
+	CaseSensitiveLocalPart bool
+}
+
+// CanonicalEmail checks the address against the addr-spec syntax of RFC 5322, extended to UTF-8 by
+// RFC 6532, and returns its canonical form: without the surrounding spaces, NFC normalized, with the
+// domain in ASCII and the local part lower-cased unless the rules keep its case. Two addresses are the
+// same when their canonical forms are equal
+func CanonicalEmail(address string, rules EmailRules) (string, bool) {
+	address = norm.NFC.String(strings.TrimSpace(address))
+
+	// the local part can have a quoted "@", the domain can't
+	at := strings.LastIndexByte(address, '@')
+	if at < 0 {
+		return "", false
+	}
+
+	local, domain := address[:at], address[at+1:]
+	if !validLocalPart(local) {
+		return "", false
+	}
+
+	domain, ok := CanonicalDomain(domain)
+	if !ok || !strings.Contains(domain, ".") {
+		return "", false
+	}
+
+	if !rules.CaseSensitiveLocalPart {
+		local = strings.ToLower(local)
+	}
+
+	canonical := local + "@" + domain
+	return canonical, len(canonical) <= emailMaxLength
+}
+
+// CanonicalDomain checks the domain name and returns its ASCII form, as the IDNA lookups of UTS #46
+// do: mapped, ex: the full width letters and the ideographic full stop, lower-cased and with the
+// internationalized labels in punycode, ex: Bücher.Example becomes xn--bcher-kva.example
+func CanonicalDomain(domain string) (string, bool) {
+	domain = norm.NFC.String(strings.TrimSpace(domain))
+	if domain == "" || !utf8.ValidString(domain) {
+		return "", false
+	}
+
+	// the punycode labels are decoded and checked too
+	ascii, err := idna.Lookup.ToASCII(domain)
+	if err != nil {
+		return "", false
+	}
+	unicodeDomain, err := idna.Lookup.ToUnicode(ascii)
+	if err != nil {
+		return "", false
+	}
+
+	// UTS #46 still maps the symbols, ex: ☃, that IDNA 2008 doesn't allow anymore
+	for _, r := range unicodeDomain {
+		if r >= utf8.RuneSelf && !unicode.In(r, unicode.L, unicode.M, unicode.Nd) &&
+			!unicode.Is(idnaContextRunes, r) {
+			return "", false
+		}
+	}
+
+	for _, label := range strings.Split(ascii, ".") {
+		if !validLabel(label) {
+			return "", false
+		}
+	}
+
+	return ascii, len(ascii) <= domainMaxLength
+}
+
+// validLocalPart checks the dot-atom or quoted-string syntax of the local part
+func validLocalPart(local string) bool {
+	if local == "" || len(local) > emailLocalMaxLength || !utf8.ValidString(local) {
+		return false
+	}
+
+	if strings.HasPrefix(local, `"`) {
+		return validQuotedString(local)
+	}
+
+	// leading, trailing and consecutive dots result in empty atoms
+	for _, atom := range strings.Split(local, ".") {
+		if atom == "" {
+			return false
+		}
+		for _, r := range atom {
+			if !isAtext(r) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// validQuotedString checks a quoted local part, ex: "john doe", where only the quotes and the
+// backslashes must be escaped
+func validQuotedString(s string) bool {
+	if len(s) < 2 || s[len(s)-1] != '"' {
+		return false
+	}
+
+	inner := s[1 : len(s)-1]
+	for i := 0; i < len(inner); i++ {
+		switch c := inner[i]; {
+		case c == '\\':
+			i++
+			if i == len(inner) || !isQuotable(inner[i]) {
+				return false
+			}
+		case c == '"':
+			return false
+		case !isQuotable(c) && c < utf8.RuneSelf:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isAtext checks if the rune can be part of an atom, RFC 6532 allows every non-ASCII printable rune
+func isAtext(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case r < utf8.RuneSelf:
+		return strings.ContainsRune(atextSpecials, r)
+	default:
+		return unicode.IsPrint(r)
+	}
+}
+
+// isQuotable checks if the ASCII char can be in a quoted string: the visible ones and the white spaces
+func isQuotable(c byte) bool {
+	return c == ' ' || c == '\t' || c >= '!' && c <= '~'
+}
+
+// validLabel checks the letters, digits and hyphens syntax of the ASCII labels of the domain
+func validLabel(label string) bool {
+	if label == "" || len(label) > labelMaxLength || label[0] == '-' || label[len(label)-1] == '-' {
+		return false
+	}
+
+	for i := 0; i < len(label); i++ {
+		c := label[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package entity
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalEmail(t *testing.T) {
+	t.Run("should canonicalize the valid addresses", func(t *testing.T) {
+		for address, canonical := range map[string]string{
+			"foo@example.com":                 "foo@example.com",
+			" Foo@Example.COM ":               "foo@example.com",
+			"first.last+tag@sub.example.com":  "first.last+tag@sub.example.com",
+			"o'hara!#$%&*/=?^_`{|}~@x.io":     "o'hara!#$%&*/=?^_`{|}~@x.io",
+			`"john doe"@example.com`:          `"john doe"@example.com`,
+			`"a\"b@c"@example.com`:            `"a\"b@c"@example.com`,
+			"josé@example.com":                "josé@example.com",
+			"user@Bücher.example":             "user@xn--bcher-kva.example",
+			"user@münchen.de":                 "user@xn--mnchen-3ya.de",
+			"user@例え.テスト":                     "user@xn--r8jz45g.xn--zckzah",
+			"user@xn--bcher-kva.example":      "user@xn--bcher-kva.example",
+			"user@my-host.example-domain.org": "user@my-host.example-domain.org",
+			"foo@ＥＸＡＭＰＬＥ.com":                 "foo@example.com",
+			"a@x。com":                         "a@x.com",
+			"user@faß.de":                     "user@xn--fa-hia.de",
+			"user@l·l.cat":                    "user@xn--ll-0ea.cat",
+			"jos\u0065\u0301@example.com":     "jos\u00e9@example.com",
+		} {
+			actual, ok := CanonicalEmail(address, EmailRules{})
+			assert.True(t, ok, address)
+			assert.Equal(t, canonical, actual, address)
+		}
+	})
+
+	t.Run("should refuse the invalid addresses", func(t *testing.T) {
+		for _, address := range []string{
+			"",
+			"foo",
+			"@example.com",
+			"foo@",
+			"foo@localhost",
+			"foo@@example.com",
+			".foo@example.com",
+			"foo.@example.com",
+			"foo..bar@example.com",
+			"foo bar@example.com",
+			"foo(bar)@example.com",
+			`"foo@example.com`,
+			`"fo"o"@example.com`,
+			`"foo\"@example.com`,
+			"foo@-example.com",
+			"foo@example-.com",
+			"foo@exa_mple.com",
+			"foo@example..com",
+			"foo@example.com.",
+			"foo@bü cher.example",
+			strings.Repeat("a", 65) + "@example.com",
+			"foo@" + strings.Repeat("a", 64) + ".com",
+			"foo@" + strings.Repeat(strings.Repeat("a", 63)+".", 4) + "com",
+			"a@☃.com",
+			"a@xn--n3h.com",
+			"a@xn--abc.com",
+		} {
+			_, ok := CanonicalEmail(address, EmailRules{})
+			assert.False(t, ok, address)
+		}
+	})
+
+	t.Run("should keep the case of the local part when the rules ask for it", func(t *testing.T) {
+		canonical, ok := CanonicalEmail(" Foo.Bar@Example.COM ", EmailRules{CaseSensitiveLocalPart: true})
+		assert.True(t, ok)
+		assert.Equal(t, "Foo.Bar@example.com", canonical)
+	})
+}
+
+func TestCanonicalDomain(t *testing.T) {
+	t.Run("should accept a single label, that is what the domain filters have", func(t *testing.T) {
+		domain, ok := CanonicalDomain("COM")
+		assert.True(t, ok)
+		assert.Equal(t, "com", domain)
+	})
+
+	t.Run("should encode the internationalized labels", func(t *testing.T) {
+		domain, ok := CanonicalDomain("Bücher.Example")
+		assert.True(t, ok)
+		assert.Equal(t, "xn--bcher-kva.example", domain)
+	})
+
+	t.Run("should have the same form for the composed and the decomposed accents", func(t *testing.T) {
+		composed, ok := CanonicalDomain("b\u00fccher.example")
+		assert.True(t, ok)
+		decomposed, ok := CanonicalDomain("bu\u0308cher.example")
+		assert.True(t, ok)
+		assert.Equal(t, composed, decomposed)
+	})
+}
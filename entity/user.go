@@ -4,9 +4,13 @@
 
 package entity
 
+import "time"
+
 // User ...
 type User struct {
-	ID    int64
-	Name  string
-	Email string
+	ID           int64
+	Name         string
+	Email        string // canonical form, see CanonicalEmail, the one that must be unique
+	DisplayEmail string // as informed by the user
+	CreatedAt    time.Time
 }
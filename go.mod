@@ -1,17 +1,32 @@
 module github.com/dougefr/go-clean-arch
 
-go 1.14
+go 1.17
 
 require (
 	github.com/DATA-DOG/go-sqlmock v1.4.1
+	github.com/go-sql-driver/mysql v1.7.1
 	github.com/gofiber/fiber v1.9.6
 	github.com/golang/mock v1.4.3
 	github.com/google/uuid v1.1.1
 	github.com/jinzhu/gorm v1.9.12
-	github.com/klauspost/compress v1.10.6 // indirect
-	github.com/kr/pretty v0.2.0 // indirect
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v2.0.1+incompatible
 	github.com/sirupsen/logrus v1.6.0
 	github.com/stretchr/testify v1.5.1
+	golang.org/x/net v0.17.0
+	golang.org/x/text v0.13.0
+	gopkg.in/yaml.v2 v2.3.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/gorilla/schema v1.1.0 // indirect
+	github.com/klauspost/compress v1.10.6 // indirect
+	github.com/kr/pretty v0.2.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasthttp v1.12.0 // indirect
+	golang.org/x/sys v0.13.0 // indirect
+	golang.org/x/tools v0.7.0 // indirect
 	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
-	gopkg.in/yaml.v2 v2.3.0 // indirect
 )
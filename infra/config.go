@@ -0,0 +1,365 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/go-sql-driver/mysql"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// DialectMemory keeps the data in memory, it's only known by the user-api, which loses every data
+// when it stops
+const DialectMemory iinfra.Dialect = "memory"
+
+// Log formats
+const (
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+)
+
+// Trace exporters, none drops the spans
+const (
+	TraceExporterNone   = "none"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+// envPrefix starts the env vars of the settings, so they don't collide with the ones of the platform, ex:
+// USER_API_DB_DSN
+const envPrefix = "USER_API_"
+
+// configFileEnv is the env var of the config file, the -config flag wins over it
+const configFileEnv = envPrefix + "CONFIG_FILE"
+
+// redacted replaces the secrets in the printed config
+const redacted = "xxxxx"
+
+// postgresPassword matches the password of the key=value postgres DSNs, quoted or not
+var postgresPassword = regexp.MustCompile(`password=('(\\.|[^'])*'|\S+)`)
+
+type (
+	// Config of the commands, see LoadConfig
+	Config struct {
+		Database DatabaseConfig `yaml:"database"`
+		Log      LogConfig      `yaml:"log"`
+		HTTP     HTTPConfig     `yaml:"http"`
+		Trace    TraceConfig    `yaml:"trace"`
+		Email    EmailConfig    `yaml:"email"`
+	}
+
+	// DatabaseConfig ...
+	DatabaseConfig struct {
+		Dialect iinfra.Dialect `yaml:"dialect"`
+		DSN     string         `yaml:"dsn"` // the file of sqlite3, test.db when empty
+		// the pool settings, zero keeps the default of the dialect
+		MaxOpenConns    int           `yaml:"max_open_conns"`
+		MaxIdleConns    int           `yaml:"max_idle_conns"`
+		ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	}
+
+	// LogConfig ...
+	LogConfig struct {
+		Level  string `yaml:"level"`
+		Format string `yaml:"format"` // json or text
+	}
+
+	// HTTPConfig ...
+	HTTPConfig struct {
+		Addr string `yaml:"addr"`
+		// RequestTimeout is the time a request has to finish before its database calls are cancelled
+		RequestTimeout time.Duration `yaml:"request_timeout"`
+		// the timeouts of the connections, zero means no timeout
+		ReadTimeout  time.Duration `yaml:"read_timeout"`
+		WriteTimeout time.Duration `yaml:"write_timeout"`
+		// ShutdownTimeout is the time the requests in flight have to finish once the server is asked
+		// to stop, the ones still running are cancelled and their transactions rolled back
+		ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+		// RequireTx makes the gateway calls of the user requests fail with iinfra.ErrTxRequired when they
+		// are made outside of a transaction, so the ones missing their unit of work are caught
+		RequireTx bool `yaml:"require_tx"`
+	}
+
+	// TraceConfig ...
+	TraceConfig struct {
+		Exporter string `yaml:"exporter"` // none, stdout or file
+		File     string `yaml:"file"`     // the file of the file exporter, the spans are appended to it
+	}
+
+	// EmailConfig ...
+	EmailConfig struct {
+		// CaseSensitiveLocalPart keeps the case of the local part of the addresses, so Foo@ and foo@ are
+		// different users. The migration that canonicalizes the stored emails uses it, changing it later
+		// doesn't change them
+		CaseSensitiveLocalPart bool `yaml:"case_sensitive_local_part"`
+	}
+
+	// configSetting is a setting that the env vars and the flags can override
+	configSetting struct {
+		flag  string // the env var is its upper snake case after envPrefix, ex: db-dsn and USER_API_DB_DSN
+		usage string
+		field func(cfg *Config) interface{} // pointer to the field, *string, *int, *bool or *time.Duration
+	}
+)
+
+// configSettings lists the settings of Config that the env vars and the flags can override
+var configSettings = []configSetting{
+	{"db-dialect", "database dialect: sqlite3, postgres, mysql or memory",
+		func(cfg *Config) interface{} { return &cfg.Database.Dialect }},
+	{"db-dsn", "database DSN, the file of sqlite3",
+		func(cfg *Config) interface{} { return &cfg.Database.DSN }},
+	{"db-max-open-conns", "max open connections of the pool, 0 keeps the default of the dialect",
+		func(cfg *Config) interface{} { return &cfg.Database.MaxOpenConns }},
+	{"db-max-idle-conns", "max idle connections of the pool, 0 keeps the default of the dialect",
+		func(cfg *Config) interface{} { return &cfg.Database.MaxIdleConns }},
+	{"db-conn-max-lifetime", "max lifetime of the connections, 0 keeps the default of the dialect",
+		func(cfg *Config) interface{} { return &cfg.Database.ConnMaxLifetime }},
+	{"log-level", "log level: panic, fatal, error, warn, info, debug or trace",
+		func(cfg *Config) interface{} { return &cfg.Log.Level }},
+	{"log-format", "log format: json or text",
+		func(cfg *Config) interface{} { return &cfg.Log.Format }},
+	{"http-addr", "address the HTTP server listens to",
+		func(cfg *Config) interface{} { return &cfg.HTTP.Addr }},
+	{"http-request-timeout", "time a request has to finish",
+		func(cfg *Config) interface{} { return &cfg.HTTP.RequestTimeout }},
+	{"http-read-timeout", "time to read a request, 0 means no timeout",
+		func(cfg *Config) interface{} { return &cfg.HTTP.ReadTimeout }},
+	{"http-write-timeout", "time to write a response, 0 means no timeout",
+		func(cfg *Config) interface{} { return &cfg.HTTP.WriteTimeout }},
+	{"http-shutdown-timeout", "time the requests in flight have to finish when the server stops",
+		func(cfg *Config) interface{} { return &cfg.HTTP.ShutdownTimeout }},
+	{"http-require-tx", "fail the database calls of the user requests made outside of a transaction",
+		func(cfg *Config) interface{} { return &cfg.HTTP.RequireTx }},
+	{"trace-exporter", "exporter of the trace spans: none, stdout or file",
+		func(cfg *Config) interface{} { return &cfg.Trace.Exporter }},
+	{"trace-file", "file the spans are appended to by the file exporter",
+		func(cfg *Config) interface{} { return &cfg.Trace.File }},
+	{"email-case-sensitive-local-part", "keep the case of the local part of the emails",
+		func(cfg *Config) interface{} { return &cfg.Email.CaseSensitiveLocalPart }},
+}
+
+// DefaultConfig is the config when no setting is given
+func DefaultConfig() Config {
+	return Config{
+		Database: DatabaseConfig{
+			Dialect: iinfra.DialectSQLite3,
+		},
+		Log: LogConfig{
+			Level:  "debug",
+			Format: LogFormatJSON,
+		},
+		HTTP: HTTPConfig{
+			Addr:            ":8080",
+			RequestTimeout:  30 * time.Second,
+			ShutdownTimeout: 15 * time.Second,
+		},
+		Trace: TraceConfig{
+			Exporter: TraceExporterNone,
+		},
+	}
+}
+
+// LoadConfig loads the settings over DefaultConfig from the YAML file of the -config flag or of the
+// USER_API_CONFIG_FILE env var, then from the env vars, then from the flags, each one overriding the previous.
+// lookupEnv is usually os.LookupEnv, the args don't have the command name. The args left after the
+// flags are returned
+func LoadConfig(name string, args []string, lookupEnv func(string) (string, bool)) (cfg Config, rest []string,
+	err error) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := fs.String("config", "", "YAML config file, also "+configFileEnv)
+	for _, setting := range configSettings {
+		fs.String(setting.flag, "", setting.usage+", also "+setting.env())
+	}
+	if err = fs.Parse(args); err != nil {
+		return
+	}
+
+	cfg = DefaultConfig()
+
+	if *file == "" {
+		*file, _ = lookupEnv(configFileEnv)
+	}
+	if *file != "" {
+		var b []byte
+		if b, err = ioutil.ReadFile(*file); err != nil {
+			return
+		}
+		// a misspelled setting must not be silently ignored
+		if err = yaml.UnmarshalStrict(b, &cfg); err != nil {
+			err = fmt.Errorf("config file %s: %w", *file, err)
+			return
+		}
+	}
+
+	for _, setting := range configSettings {
+		if value, ok := lookupEnv(setting.env()); ok {
+			if err = setting.set(&cfg, value); err != nil {
+				err = fmt.Errorf("env var %s: %w", setting.env(), err)
+				return
+			}
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		for _, setting := range configSettings {
+			if setting.flag == f.Name && err == nil {
+				if err = setting.set(&cfg, f.Value.String()); err != nil {
+					err = fmt.Errorf("flag -%s: %w", f.Name, err)
+				}
+			}
+		}
+	})
+	if err != nil {
+		return
+	}
+
+	return cfg, fs.Args(), cfg.Validate()
+}
+
+// Validate checks every setting, so a bad config stops the command at the startup
+func (c Config) Validate() error {
+	var problems []string
+
+	switch c.Database.Dialect {
+	case iinfra.DialectSQLite3, DialectMemory:
+	case iinfra.DialectPostgres, iinfra.DialectMySQL:
+		if c.Database.DSN == "" {
+			problems = append(problems, fmt.Sprintf("database dsn is required by %s", c.Database.Dialect))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown database dialect: %q", c.Database.Dialect))
+	}
+	if c.Database.MaxOpenConns < 0 || c.Database.MaxIdleConns < 0 || c.Database.ConnMaxLifetime < 0 {
+		problems = append(problems, "database pool settings cannot be negative")
+	}
+	if c.Database.MaxOpenConns > 0 && c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		problems = append(problems, "database max idle conns cannot be greater than the max open conns")
+	}
+
+	if _, err := log.ParseLevel(c.Log.Level); err != nil {
+		problems = append(problems, fmt.Sprintf("unknown log level: %q", c.Log.Level))
+	}
+	if c.Log.Format != LogFormatJSON && c.Log.Format != LogFormatText {
+		problems = append(problems, fmt.Sprintf("unknown log format: %q", c.Log.Format))
+	}
+
+	if c.HTTP.Addr == "" {
+		problems = append(problems, "http addr is required")
+	}
+	if c.HTTP.RequestTimeout <= 0 {
+		problems = append(problems, "http request timeout must be positive")
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		problems = append(problems, "http shutdown timeout must be positive")
+	}
+	if c.HTTP.ReadTimeout < 0 || c.HTTP.WriteTimeout < 0 {
+		problems = append(problems, "http timeouts cannot be negative")
+	}
+
+	switch c.Trace.Exporter {
+	case TraceExporterNone, TraceExporterStdout:
+	case TraceExporterFile:
+		if c.Trace.File == "" {
+			problems = append(problems, "trace file is required by the file exporter")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown trace exporter: %q", c.Trace.Exporter))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Redacted is the config without its secrets, so it can be printed
+func (c Config) Redacted() Config {
+	c.Database.DSN = redactDSN(c.Database.Dialect, c.Database.DSN)
+	return c
+}
+
+// LogAttrs lists the settings by their flags, so they can be logged. Use it on the Redacted config
+func (c Config) LogAttrs() iinfra.LogAttrs {
+	attrs := make(iinfra.LogAttrs, len(configSettings))
+	for _, setting := range configSettings {
+		attrs[setting.flag] = setting.get(&c)
+	}
+	return attrs
+}
+
+// redactDSN hides the password of the DSN, or the whole DSN when it can't be parsed
+func redactDSN(dialect iinfra.Dialect, dsn string) string {
+	switch {
+	case dsn == "":
+		return dsn
+	case strings.Contains(dsn, "://"):
+		if u, err := url.Parse(dsn); err == nil {
+			return u.Redacted()
+		}
+	case dialect == iinfra.DialectSQLite3:
+		return dsn
+	case dialect == iinfra.DialectMySQL:
+		if cfg, err := mysql.ParseDSN(dsn); err == nil {
+			if cfg.Passwd != "" {
+				cfg.Passwd = redacted
+			}
+			return cfg.FormatDSN()
+		}
+	case dialect == iinfra.DialectPostgres:
+		return postgresPassword.ReplaceAllString(dsn, "password="+redacted)
+	}
+
+	return redacted
+}
+
+// env is the env var of the setting
+func (s configSetting) env() string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(s.flag, "-", "_"))
+}
+
+// get formats the field of the setting
+func (s configSetting) get(cfg *Config) string {
+	switch field := s.field(cfg).(type) {
+	case *string:
+		return *field
+	case *iinfra.Dialect:
+		return string(*field)
+	case *int:
+		return strconv.Itoa(*field)
+	case *bool:
+		return strconv.FormatBool(*field)
+	case *time.Duration:
+		return field.String()
+	}
+	return ""
+}
+
+// set parses the value into the field of the setting
+func (s configSetting) set(cfg *Config, value string) (err error) {
+	switch field := s.field(cfg).(type) {
+	case *string:
+		*field = value
+	case *iinfra.Dialect:
+		*field = iinfra.Dialect(value)
+	case *int:
+		*field, err = strconv.Atoi(value)
+	case *bool:
+		*field, err = strconv.ParseBool(value)
+	case *time.Duration:
+		*field, err = time.ParseDuration(value)
+	}
+	return
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// NewDatabase connects to a database of the dialect, the dsn of sqlite3 is the database file. The pool
+// settings that are zero keep the defaults of the dialect
+func NewDatabase(cfg DatabaseConfig) (db iinfra.Database, err error) {
+	switch cfg.Dialect {
+	case iinfra.DialectSQLite3, "":
+		db, err = NewSQLite3(cfg.DSN)
+	case iinfra.DialectPostgres:
+		db, err = NewPostgres(cfg.DSN)
+	case iinfra.DialectMySQL:
+		db, err = NewMySQL(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unknown database dialect: %q", cfg.Dialect)
+	}
+	if err != nil {
+		return
+	}
+
+	if s, ok := db.(sqlDatabase); ok {
+		if cfg.MaxOpenConns > 0 {
+			s.db.SetMaxOpenConns(cfg.MaxOpenConns)
+		}
+		if cfg.MaxIdleConns > 0 {
+			s.db.SetMaxIdleConns(cfg.MaxIdleConns)
+		}
+		if cfg.ConnMaxLifetime > 0 {
+			s.db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		}
+	}
+
+	return
+}
+
+// DatabasePoolCollector returns a function that sets the gauges of the stats of the database pool,
+// to be called before the metrics are collected, see Prometheus.OnCollect
+func DatabasePoolCollector(db iinfra.Database, metrics iinfra.MetricsProvider) func() {
+	return func() {
+		s, ok := db.(sqlDatabase)
+		if !ok {
+			return
+		}
+
+		stats := s.db.Stats()
+		labels := iinfra.MetricLabels{"dialect": string(s.dialect)}
+		metrics.Gauge("db_pool_max_open_connections", labels, float64(stats.MaxOpenConnections))
+		metrics.Gauge("db_pool_open_connections", labels, float64(stats.OpenConnections))
+		metrics.Gauge("db_pool_in_use_connections", labels, float64(stats.InUse))
+		metrics.Gauge("db_pool_idle_connections", labels, float64(stats.Idle))
+		// the stats are cumulative, so they are set instead of added
+		metrics.Gauge("db_pool_wait_count", labels, float64(stats.WaitCount))
+		metrics.Gauge("db_pool_wait_duration_seconds", labels, stats.WaitDuration.Seconds())
+		metrics.Gauge("db_pool_max_idle_closed", labels, float64(stats.MaxIdleClosed))
+		metrics.Gauge("db_pool_max_lifetime_closed", labels, float64(stats.MaxLifetimeClosed))
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+type databaseChecker struct {
+	db iinfra.Database
+}
+
+// NewDatabaseChecker checks if the database can be reached
+func NewDatabaseChecker(db iinfra.Database) iinfra.HealthChecker {
+	return databaseChecker{
+		db: db,
+	}
+}
+
+// Name ...
+func (d databaseChecker) Name() string {
+	return "database"
+}
+
+// Check ...
+func (d databaseChecker) Check(ctx context.Context) error {
+	return d.db.Ping(ctx)
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseChecker(t *testing.T) {
+	t.Run("should ping the database", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		require.Nil(t, err)
+		defer db.Close()
+
+		expectedErr := errors.New("fake-error")
+		mock.ExpectPing()
+		mock.ExpectPing().WillReturnError(expectedErr)
+
+		checker := NewDatabaseChecker(sqlDatabase{db: db, dialect: iinfra.DialectPostgres})
+		assert.Equal(t, "database", checker.Name())
+		assert.NoError(t, checker.Check(context.Background()))
+		assert.Equal(t, expectedErr, checker.Check(context.Background()))
+
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
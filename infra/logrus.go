@@ -6,7 +6,9 @@ package infra
 
 import (
 	"context"
+	"fmt"
 	"runtime"
+	"sync"
 
 	"github.com/dougefr/go-clean-arch/interface/iinfra"
 	log "github.com/sirupsen/logrus"
@@ -14,10 +16,12 @@ import (
 
 type loggerProvider struct {
 	l *log.Logger
+	// pending are the info logs being written in background
+	pending *sync.WaitGroup
 }
 
-// NewLogrus ...
-func NewLogrus(logLevel string) (l iinfra.LogProvider, err error) {
+// NewLogrus builds a logger of the level, the format is json or text
+func NewLogrus(logLevel, format string) (l iinfra.LogProvider, err error) {
 	var level log.Level
 	level, err = log.ParseLevel(logLevel)
 	if err != nil {
@@ -26,9 +30,18 @@ func NewLogrus(logLevel string) (l iinfra.LogProvider, err error) {
 
 	logger := log.New()
 	logger.SetLevel(level)
-	logger.SetFormatter(&log.JSONFormatter{}) // set JSON formatter as default formatter
+	switch format {
+	case LogFormatJSON, "":
+		logger.SetFormatter(&log.JSONFormatter{})
+	case LogFormatText:
+		logger.SetFormatter(&log.TextFormatter{})
+	default:
+		err = fmt.Errorf("unknown log format: %q", format)
+		return
+	}
 	l = loggerProvider{
-		l: logger,
+		l:       logger,
+		pending: &sync.WaitGroup{},
 	}
 
 	return
@@ -36,7 +49,9 @@ func NewLogrus(logLevel string) (l iinfra.LogProvider, err error) {
 
 func (l loggerProvider) Info(ctx context.Context, message string, attrs ...iinfra.LogAttrs) {
 	if l.l.IsLevelEnabled(log.InfoLevel) {
+		l.pending.Add(1)
 		go func() {
+			defer l.pending.Done()
 			attrs = appendGlobalAttrs(ctx, attrs)
 			attrs = append(attrs, iinfra.LogAttrs{"func": trace()})
 			l.l.WithFields(mergeAttrs(attrs)).Info(message)
@@ -68,9 +83,14 @@ func (l loggerProvider) Warn(ctx context.Context, message string, attrs ...iinfr
 	}
 }
 
+// Flush ...
+func (l loggerProvider) Flush() {
+	l.pending.Wait()
+}
+
 // appendGlobalAttrs get global attrs from the context
 func appendGlobalAttrs(ctx context.Context, attrs []iinfra.LogAttrs) []iinfra.LogAttrs {
-	if a, ok := ctx.Value(iinfra.ContextKeyGlobalLogAttrs).(iinfra.LogAttrs); ok {
+	if a := iinfra.LogAttrsFromContext(ctx); a != nil {
 		attrs = append(attrs, a)
 	}
 	return attrs
@@ -0,0 +1,72 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/go-sql-driver/mysql"
+)
+
+// connection pool settings, the lifetime must be lower than the wait_timeout of the server
+// and the proxies in between, otherwise the driver would pick connections already closed
+const (
+	mysqlMaxOpenConns    = 25
+	mysqlMaxIdleConns    = 25
+	mysqlConnMaxLifetime = 3 * time.Minute
+)
+
+// MySQL and MariaDB error numbers of duplicate keys
+const (
+	mysqlErrDupEntry            = 1062
+	mysqlErrDupEntryWithKeyName = 1586
+)
+
+// NewMySQL connects to a MySQL or MariaDB database, ex of dsn: user:pass@tcp(localhost:3306)/users
+func NewMySQL(dsn string) (iinfra.Database, error) {
+	cfg, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ParseTime = true // scan DATETIME columns into time.Time
+	cfg.Loc = time.UTC   // the gateways always store UTC times
+
+	db, err := sql.Open("mysql", cfg.FormatDSN())
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(mysqlMaxOpenConns)
+	db.SetMaxIdleConns(mysqlMaxIdleConns)
+	db.SetConnMaxLifetime(mysqlConnMaxLifetime)
+
+	// sql.Open is lazy, fail fast if the server can't be reached
+	if err = db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return sqlDatabase{
+		db:           db,
+		dialect:      iinfra.DialectMySQL,
+		translateErr: translateMySQLErr,
+	}, nil
+}
+
+// translateMySQLErr wraps the duplicate key errors with iinfra.ErrUniqueViolation
+func translateMySQLErr(err error) error {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		switch mysqlErr.Number {
+		case mysqlErrDupEntry, mysqlErrDupEntryWithKeyName:
+			return fmt.Errorf("%w: %v", iinfra.ErrUniqueViolation, err)
+		}
+	}
+
+	return err
+}
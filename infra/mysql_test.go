@@ -0,0 +1,73 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateMySQLErr(t *testing.T) {
+	t.Run("should wrap the duplicate key errors with ErrUniqueViolation", func(t *testing.T) {
+		for _, number := range []uint16{mysqlErrDupEntry, mysqlErrDupEntryWithKeyName} {
+			err := translateMySQLErr(&mysql.MySQLError{Number: number, Message: "Duplicate entry"})
+			assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation), number)
+		}
+	})
+
+	t.Run("should keep the other errors", func(t *testing.T) {
+		mysqlErr := &mysql.MySQLError{Number: 1146, Message: "Table doesn't exist"}
+		assert.Equal(t, mysqlErr, translateMySQLErr(mysqlErr))
+	})
+
+	t.Run("should translate the errors of the statements", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec("INSERT INTO users").WillReturnError(&mysql.MySQLError{Number: mysqlErrDupEntry})
+		mock.ExpectQuery("SELECT id FROM users").WillReturnError(&mysql.MySQLError{Number: mysqlErrDupEntry})
+
+		s := sqlDatabase{db: db, dialect: iinfra.DialectMySQL, translateErr: translateMySQLErr}
+		_, err = s.Exec(context.Background(), "INSERT INTO users (email) VALUES (?)", "fake@email.com")
+		assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation))
+		_, err = s.Query(context.Background(), "SELECT id FROM users")
+		assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation))
+	})
+}
+
+func TestNewMySQL(t *testing.T) {
+	t.Run("should return an error if the dsn is malformed", func(t *testing.T) {
+		_, err := NewMySQL("user:pass@localhost:3306/users")
+		assert.Error(t, err)
+	})
+
+	t.Run("should return an error if the server can't be reached", func(t *testing.T) {
+		_, err := NewMySQL("user:pass@tcp(127.0.0.1:1)/users")
+		assert.Error(t, err)
+	})
+	t.Run("should refuse the stacked statements", func(t *testing.T) {
+		// the server is external, the test only runs when the DSN of a disposable database is given
+		dsn := os.Getenv("TEST_MYSQL_DSN")
+		if dsn == "" {
+			t.Skip("TEST_MYSQL_DSN not set")
+		}
+
+		db, err := NewMySQL(dsn)
+		require.Nil(t, err)
+		defer db.Close()
+
+		_, err = db.Exec(context.Background(), "SELECT 1; SELECT 2")
+		assert.Error(t, err)
+	})
+}
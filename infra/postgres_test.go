@@ -0,0 +1,57 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslatePostgresErr(t *testing.T) {
+	t.Run("should wrap the unique violations with ErrUniqueViolation", func(t *testing.T) {
+		err := translatePostgresErr(&pq.Error{Code: postgresUniqueViolation, Message: "duplicate key value"})
+		assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation))
+
+		// the driver error can come wrapped
+		err = translatePostgresErr(fmt.Errorf("insert: %w", &pq.Error{Code: postgresUniqueViolation}))
+		assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation))
+	})
+
+	t.Run("should keep the other errors", func(t *testing.T) {
+		pqErr := &pq.Error{Code: "23502", Message: "null value in column"}
+		assert.Equal(t, pqErr, translatePostgresErr(pqErr))
+	})
+	t.Run("should translate the violations of a real database", func(t *testing.T) {
+		// the server is external, the test only runs when the DSN of a disposable database is given
+		dsn := os.Getenv("TEST_POSTGRES_DSN")
+		if dsn == "" {
+			t.Skip("TEST_POSTGRES_DSN not set")
+		}
+
+		db, err := NewPostgres(dsn)
+		require.Nil(t, err)
+		defer db.Close()
+
+		ctx := context.Background()
+		_, err = db.Exec(ctx, "CREATE TABLE translate_test (email TEXT UNIQUE)")
+		require.Nil(t, err)
+		defer func() {
+			_, _ = db.Exec(ctx, "DROP TABLE translate_test")
+		}()
+		_, err = db.Exec(ctx, "INSERT INTO translate_test (email) VALUES ($1)", "fake@email.com")
+		require.Nil(t, err)
+
+		_, err = db.Exec(ctx, "INSERT INTO translate_test (email) VALUES ($1)", "fake@email.com")
+		assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation), err)
+	})
+}
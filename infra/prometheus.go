@@ -0,0 +1,241 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Kinds of the Prometheus metrics
+const (
+	prometheusCounter   = "counter"
+	prometheusGauge     = "gauge"
+	prometheusHistogram = "histogram"
+)
+
+// prometheusBuckets are the upper bounds of the histogram buckets, they fit latencies in seconds
+var prometheusBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+var (
+	prometheusMetricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+	prometheusLabelName  = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	// the label values are quoted, so these characters are escaped
+	prometheusLabelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+type (
+	// Prometheus keeps the metrics in memory and writes them in the Prometheus text exposition format
+	Prometheus struct {
+		mu         sync.Mutex
+		metrics    map[string]*prometheusMetric
+		collectors []func()
+	}
+
+	prometheusMetric struct {
+		kind       string
+		labelNames []string
+		series     map[string]*prometheusSeries // by the formatted label values
+	}
+
+	prometheusSeries struct {
+		labels string  // formatted for the exposition, ex: method="GET",route="/user"
+		value  float64 // the sum of the observations of a histogram
+		count  uint64
+		// the observations of a histogram by bucket, each one is only counted in its lowest bucket
+		buckets []uint64
+	}
+)
+
+// NewPrometheus ...
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		metrics: make(map[string]*prometheusMetric),
+	}
+}
+
+// Counter ...
+func (p *Prometheus) Counter(name string, labels iinfra.MetricLabels, value float64) {
+	if value < 0 {
+		panic(fmt.Sprintf("counter %s cannot be decreased", name))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.series(name, prometheusCounter, labels).value += value
+}
+
+// Gauge ...
+func (p *Prometheus) Gauge(name string, labels iinfra.MetricLabels, value float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.series(name, prometheusGauge, labels).value = value
+}
+
+// Histogram ...
+func (p *Prometheus) Histogram(name string, labels iinfra.MetricLabels, value float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.series(name, prometheusHistogram, labels)
+	if s.buckets == nil {
+		s.buckets = make([]uint64, len(prometheusBuckets))
+	}
+	// the observations above the last bucket are only in the +Inf one, which is the count
+	if i := sort.SearchFloat64s(prometheusBuckets, value); i < len(prometheusBuckets) {
+		s.buckets[i]++
+	}
+	s.value += value
+	s.count++
+}
+
+// OnCollect calls fn before writing the metrics, so the gauges of values that are read instead of
+// measured are up to date, ex: the stats of the database pool
+func (p *Prometheus) OnCollect(fn func()) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.collectors = append(p.collectors, fn)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format, sorted by name and labels
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	collectors := p.collectors
+	p.mu.Unlock()
+	for _, collect := range collectors {
+		collect()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	names := make([]string, 0, len(p.metrics))
+	for name := range p.metrics {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		m := p.metrics[name]
+		fmt.Fprintf(&buf, "# TYPE %s %s\n", name, m.kind)
+
+		keys := make([]string, 0, len(m.series))
+		for key := range m.series {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			s := m.series[key]
+			if m.kind != prometheusHistogram {
+				fmt.Fprintf(&buf, "%s%s %s\n", name, braces(s.labels), formatFloat(s.value))
+				continue
+			}
+
+			var cumulative uint64
+			for i, bound := range prometheusBuckets {
+				cumulative += s.buckets[i]
+				fmt.Fprintf(&buf, "%s_bucket%s %d\n", name, braces(withLabel(s.labels, "le", formatFloat(bound))),
+					cumulative)
+			}
+			fmt.Fprintf(&buf, "%s_bucket%s %d\n", name, braces(withLabel(s.labels, "le", "+Inf")), s.count)
+			fmt.Fprintf(&buf, "%s_sum%s %s\n", name, braces(s.labels), formatFloat(s.value))
+			fmt.Fprintf(&buf, "%s_count%s %d\n", name, braces(s.labels), s.count)
+		}
+	}
+
+	return buf.WriteTo(w)
+}
+
+// series finds the series of the labels, creating the metric and the series if needed. Using a metric
+// with another kind or other label names is a bug, so it panics like the Prometheus client does
+func (p *Prometheus) series(name, kind string, labels iinfra.MetricLabels) *prometheusSeries {
+	labelNames := make([]string, 0, len(labels))
+	for labelName := range labels {
+		labelNames = append(labelNames, labelName)
+	}
+	sort.Strings(labelNames)
+
+	m, ok := p.metrics[name]
+	if !ok {
+		if !prometheusMetricName.MatchString(name) {
+			panic(fmt.Sprintf("invalid metric name: %q", name))
+		}
+		for _, labelName := range labelNames {
+			if !prometheusLabelName.MatchString(labelName) || labelName == "le" {
+				panic(fmt.Sprintf("invalid label name of metric %s: %q", name, labelName))
+			}
+		}
+
+		m = &prometheusMetric{
+			kind:       kind,
+			labelNames: labelNames,
+			series:     make(map[string]*prometheusSeries),
+		}
+		p.metrics[name] = m
+	}
+	if m.kind != kind {
+		panic(fmt.Sprintf("metric %s is a %s, not a %s", name, m.kind, kind))
+	}
+	if strings.Join(m.labelNames, ",") != strings.Join(labelNames, ",") {
+		panic(fmt.Sprintf("metric %s has the labels %v, not %v", name, m.labelNames, labelNames))
+	}
+
+	pairs := make([]string, len(labelNames))
+	for i, labelName := range labelNames {
+		pairs[i] = fmt.Sprintf(`%s="%s"`, labelName, prometheusLabelValueEscaper.Replace(labels[labelName]))
+	}
+	key := strings.Join(pairs, ",")
+
+	s, ok := m.series[key]
+	if !ok {
+		s = &prometheusSeries{labels: key}
+		m.series[key] = s
+	}
+	return s
+}
+
+// braces encloses the labels, if any
+func braces(labels string) string {
+	if labels == "" {
+		return ""
+	}
+	return "{" + labels + "}"
+}
+
+// withLabel appends a label to the formatted ones
+func withLabel(labels, name, value string) string {
+	pair := fmt.Sprintf(`%s="%s"`, name, value)
+	if labels == "" {
+		return pair
+	}
+	return labels + "," + pair
+}
+
+// formatFloat formats the value as Prometheus does
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheus(t *testing.T) {
+	write := func(t *testing.T, p *Prometheus) string {
+		var buf bytes.Buffer
+		_, err := p.WriteTo(&buf)
+		require.NoError(t, err)
+		return buf.String()
+	}
+
+	t.Run("should write the counters and the gauges sorted by name and labels", func(t *testing.T) {
+		p := NewPrometheus()
+		p.Counter("requests_total", iinfra.MetricLabels{"route": "/user", "method": "POST"}, 1)
+		p.Counter("requests_total", iinfra.MetricLabels{"route": "/user", "method": "GET"}, 1)
+		p.Counter("requests_total", iinfra.MetricLabels{"method": "GET", "route": "/user"}, 2)
+		p.Gauge("connections", nil, 5)
+		p.Gauge("connections", nil, 3)
+
+		assert.Equal(t, `# TYPE connections gauge
+connections 3
+# TYPE requests_total counter
+requests_total{method="GET",route="/user"} 3
+requests_total{method="POST",route="/user"} 1
+`, write(t, p))
+	})
+
+	t.Run("should write the histograms with cumulative buckets", func(t *testing.T) {
+		p := NewPrometheus()
+		p.Histogram("duration_seconds", iinfra.MetricLabels{"method": "count"}, 0.001)
+		p.Histogram("duration_seconds", iinfra.MetricLabels{"method": "count"}, 0.3)
+		p.Histogram("duration_seconds", iinfra.MetricLabels{"method": "count"}, 60)
+
+		assert.Equal(t, `# TYPE duration_seconds histogram
+duration_seconds_bucket{method="count",le="0.005"} 1
+duration_seconds_bucket{method="count",le="0.01"} 1
+duration_seconds_bucket{method="count",le="0.025"} 1
+duration_seconds_bucket{method="count",le="0.05"} 1
+duration_seconds_bucket{method="count",le="0.1"} 1
+duration_seconds_bucket{method="count",le="0.25"} 1
+duration_seconds_bucket{method="count",le="0.5"} 2
+duration_seconds_bucket{method="count",le="1"} 2
+duration_seconds_bucket{method="count",le="2.5"} 2
+duration_seconds_bucket{method="count",le="5"} 2
+duration_seconds_bucket{method="count",le="10"} 2
+duration_seconds_bucket{method="count",le="+Inf"} 3
+duration_seconds_sum{method="count"} 60.301
+duration_seconds_count{method="count"} 3
+`, write(t, p))
+	})
+
+	t.Run("should escape the label values", func(t *testing.T) {
+		p := NewPrometheus()
+		p.Counter("errors_total", iinfra.MetricLabels{"message": "a \"quoted\"\\path\nline"}, 1)
+
+		assert.Contains(t, write(t, p), `errors_total{message="a \"quoted\"\\path\nline"} 1`)
+	})
+
+	t.Run("should call the collectors before writing", func(t *testing.T) {
+		p := NewPrometheus()
+		calls := 0
+		p.OnCollect(func() {
+			calls++
+			p.Gauge("calls", nil, float64(calls))
+		})
+
+		assert.Contains(t, write(t, p), "calls 1\n")
+		assert.Contains(t, write(t, p), "calls 2\n")
+	})
+
+	t.Run("should panic when a metric is misused", func(t *testing.T) {
+		p := NewPrometheus()
+		p.Counter("requests_total", iinfra.MetricLabels{"route": "/user"}, 1)
+
+		assert.Panics(t, func() { p.Gauge("requests_total", iinfra.MetricLabels{"route": "/user"}, 1) })
+		assert.Panics(t, func() { p.Counter("requests_total", iinfra.MetricLabels{"path": "/user"}, 1) })
+		assert.Panics(t, func() { p.Counter("requests_total", iinfra.MetricLabels{"route": "/user"}, -1) })
+		assert.Panics(t, func() { p.Counter("requests-total", nil, 1) })
+		assert.Panics(t, func() { p.Histogram("duration_seconds", iinfra.MetricLabels{"le": "1"}, 1) })
+	})
+}
+
+func TestDatabasePoolCollector(t *testing.T) {
+	t.Run("should set the gauges of the pool stats", func(t *testing.T) {
+		db, err := NewDatabase(DatabaseConfig{
+			Dialect:      "sqlite3",
+			DSN:          filepath.Join(t.TempDir(), "users.db"),
+			MaxOpenConns: 4,
+		})
+		require.NoError(t, err)
+		defer db.Close()
+
+		p := NewPrometheus()
+		p.OnCollect(DatabasePoolCollector(db, p))
+
+		var buf bytes.Buffer
+		_, err = p.WriteTo(&buf)
+		require.NoError(t, err)
+
+		assert.Contains(t, buf.String(), `db_pool_max_open_connections{dialect="sqlite3"} 4`+"\n")
+		assert.Equal(t, 8, strings.Count(buf.String(), "# TYPE db_pool_"))
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// errForeignTx is returned when the transaction of the context wasn't started by a sqlDatabase
+var errForeignTx = errors.New("the transaction of the context is not a database/sql one")
+
+// sqlConn is what the statements run on, the database or a transaction of it
+type sqlConn interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// sqlDatabase implements iinfra.Database over any database/sql driver
+type sqlDatabase struct {
+	db *sql.DB
+	// readDB begins the read-only transactions when it isn't nil, ex: the sqlite3 ones that don't take the
+	// write lock
+	readDB  *sql.DB
+	dialect iinfra.Dialect
+	// translateErr wraps the driver errors known by iinfra, ex: iinfra.ErrUniqueViolation
+	translateErr func(error) error
+}
+
+// Dialect ...
+func (s sqlDatabase) Dialect() iinfra.Dialect {
+	return s.dialect
+}
+
+// Query ...
+func (s sqlDatabase) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.QueryContext(ctx, query, args...)
+	return rows, s.translate(err)
+}
+
+// Exec ...
+func (s sqlDatabase) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	conn, err := s.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := conn.ExecContext(ctx, query, args...)
+	return result, s.translate(err)
+}
+
+// Ping ...
+func (s sqlDatabase) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close ...
+func (s sqlDatabase) Close() error {
+	if s.readDB != nil {
+		_ = s.readDB.Close()
+	}
+	return s.db.Close()
+}
+
+// conn is the transaction of the context, or the database when there is none and it isn't required
+func (s sqlDatabase) conn(ctx context.Context) (sqlConn, error) {
+	tx, ok := iinfra.TxFromContext(ctx)
+	if !ok {
+		if iinfra.TxRequired(ctx) {
+			return nil, iinfra.ErrTxRequired
+		}
+		return s.db, nil
+	}
+
+	sqlTx, ok := tx.(*sql.Tx)
+	if !ok {
+		return nil, errForeignTx
+	}
+	return sqlTx, nil
+}
+
+// BeginTx ...
+func (s sqlDatabase) BeginTx(ctx context.Context, opts *sql.TxOptions) (iinfra.Tx, error) {
+	if s.readDB != nil && opts != nil && opts.ReadOnly {
+		return s.readDB.BeginTx(ctx, opts)
+	}
+	return s.db.BeginTx(ctx, opts)
+}
+
+// CommitTx ...
+func (s sqlDatabase) CommitTx(tx iinfra.Tx) error {
+	if db, ok := tx.(*sql.Tx); ok {
+		return db.Commit()
+	}
+	return nil
+}
+
+// RollbackTx ...
+func (s sqlDatabase) RollbackTx(tx iinfra.Tx) error {
+	if db, ok := tx.(*sql.Tx); ok {
+		return db.Rollback()
+	}
+	return nil
+}
+
+// Savepoint ...
+func (s sqlDatabase) Savepoint(ctx context.Context, tx iinfra.Tx, name string) error {
+	return s.execInTx(ctx, tx, "SAVEPOINT "+name)
+}
+
+// RollbackToSavepoint ...
+func (s sqlDatabase) RollbackToSavepoint(ctx context.Context, tx iinfra.Tx, name string) error {
+	return s.execInTx(ctx, tx, "ROLLBACK TO SAVEPOINT "+name)
+}
+
+// ReleaseSavepoint ...
+func (s sqlDatabase) ReleaseSavepoint(ctx context.Context, tx iinfra.Tx, name string) error {
+	return s.execInTx(ctx, tx, "RELEASE SAVEPOINT "+name)
+}
+
+// execInTx runs a statement that only makes sense inside of the transaction, the savepoint ones
+// have the same syntax in every dialect. The transactions that aren't database/sql ones can't run it
+func (s sqlDatabase) execInTx(ctx context.Context, tx iinfra.Tx, query string) error {
+	db, ok := tx.(*sql.Tx)
+	if !ok {
+		return iinfra.ErrSavepointUnsupported
+	}
+
+	_, err := db.ExecContext(ctx, query)
+	return s.translate(err)
+}
+
+// translate wraps err with the iinfra error it means, if any
+func (s sqlDatabase) translate(err error) error {
+	if err == nil || s.translateErr == nil {
+		return err
+	}
+	return s.translateErr(err)
+}
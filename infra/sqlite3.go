@@ -5,63 +5,73 @@
 package infra
 
 import (
-	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/mattn/go-sqlite3"
 )
 
-type sqlite3 struct {
-	db *sql.DB
-}
+// default file of the sqlite3 database
+const sqlite3DefaultFile = "test.db"
 
-// NewSQLite3 ...
-func NewSQLite3() (iinfra.Database, error) {
-	db, err := sql.Open("sqlite3", "test.db")
-	if err != nil {
-		return nil, err
+// NewSQLite3 opens the database file, an empty one means test.db. Query params of the driver
+// can follow the file name, ex: test.db?_busy_timeout=10000
+func NewSQLite3(file string) (iinfra.Database, error) {
+	if file == "" {
+		file = sqlite3DefaultFile
 	}
 
-	return sqlite3{
-		db: db,
-	}, nil
-}
-
-// Query ...
-func (s sqlite3) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-	if tx, ok := ctx.Value(iinfra.ContextKeyTx).(*sql.Tx); ok {
-		return tx.Query(query, args...)
+	// a deferred tx that reads before writing fails at once when another one holds the write lock,
+	// instead of waiting for it, so the lock is taken at the beginning of the tx. The read-only ones
+	// never write, so they are deferred and don't wait for the writers. A _txlock of the file is used
+	// for every tx, and an in-memory database can't be shared by two pools
+	if strings.Contains(file, "_txlock=") || strings.Contains(file, ":memory:") ||
+		strings.Contains(file, "mode=memory") {
+		db, err := sql.Open("sqlite3", file)
+		if err != nil {
+			return nil, err
+		}
+		return sqlDatabase{db: db, dialect: iinfra.DialectSQLite3, translateErr: translateSQLite3Err}, nil
 	}
 
-	return s.db.Query(query, args...)
-}
-
-// Query ...
-func (s sqlite3) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
-	if tx, ok := ctx.Value(iinfra.ContextKeyTx).(*sql.Tx); ok {
-		return tx.Exec(query, args...)
+	db, err := sql.Open("sqlite3", withSQLite3Param(file, "_txlock=immediate"))
+	if err != nil {
+		return nil, err
+	}
+	readDB, err := sql.Open("sqlite3", withSQLite3Param(file, "_txlock=deferred"))
+	if err != nil {
+		_ = db.Close()
+		return nil, err
 	}
 
-	return s.db.Exec(query, args...)
-}
-
-// BeginTx ...
-func (s sqlite3) BeginTx() (iinfra.Tx, error) {
-	return s.db.Begin()
+	return sqlDatabase{
+		db:           db,
+		readDB:       readDB,
+		dialect:      iinfra.DialectSQLite3,
+		translateErr: translateSQLite3Err,
+	}, nil
 }
 
-// CommitTx ...
-func (s sqlite3) CommitTx(tx iinfra.Tx) error {
-	if db, ok := tx.(*sql.Tx); ok {
-		return db.Commit()
+// withSQLite3Param appends the query param to the file name
+func withSQLite3Param(file, param string) string {
+	if strings.Contains(file, "?") {
+		return file + "&" + param
 	}
-	return nil
+	return file + "?" + param
 }
 
-// RollbackTx ...
-func (s sqlite3) RollbackTx(tx iinfra.Tx) error {
-	if db, ok := tx.(*sql.Tx); ok {
-		return db.Rollback()
+// translateSQLite3Err wraps the unique and primary key violations with iinfra.ErrUniqueViolation
+func translateSQLite3Err(err error) error {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch sqliteErr.ExtendedCode {
+		case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+			return fmt.Errorf("%w: %v", iinfra.ErrUniqueViolation, err)
+		}
 	}
-	return nil
+
+	return err
 }
@@ -0,0 +1,81 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateSQLite3Err(t *testing.T) {
+	t.Run("should wrap the unique and primary key violations with ErrUniqueViolation", func(t *testing.T) {
+		for _, code := range []sqlite3.ErrNoExtended{sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey} {
+			err := translateSQLite3Err(sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: code})
+			assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation), code)
+		}
+	})
+
+	t.Run("should keep the other errors", func(t *testing.T) {
+		sqliteErr := sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull}
+		assert.Equal(t, sqliteErr, translateSQLite3Err(sqliteErr))
+	})
+
+	t.Run("should translate the violations of a real database", func(t *testing.T) {
+		db, err := NewSQLite3(filepath.Join(t.TempDir(), "test.db"))
+		require.Nil(t, err)
+
+		ctx := context.Background()
+		_, err = db.Exec(ctx, "CREATE TABLE users (email TEXT UNIQUE)")
+		require.Nil(t, err)
+		_, err = db.Exec(ctx, "INSERT INTO users (email) VALUES (?)", "fake@email.com")
+		require.Nil(t, err)
+
+		_, err = db.Exec(ctx, "INSERT INTO users (email) VALUES (?)", "fake@email.com")
+		assert.True(t, errors.Is(err, iinfra.ErrUniqueViolation), err)
+	})
+}
+
+func TestNewSQLite3(t *testing.T) {
+	t.Run("should not make the read-only transactions wait for the writers", func(t *testing.T) {
+		db, err := NewSQLite3(filepath.Join(t.TempDir(), "test.db"))
+		require.Nil(t, err)
+		defer db.Close()
+
+		ctx := context.Background()
+		_, err = db.Exec(ctx, "CREATE TABLE users (email TEXT)")
+		require.Nil(t, err)
+
+		// the write tx holds the write lock from its beginning
+		writeTx, err := db.BeginTx(ctx, nil)
+		require.Nil(t, err)
+		defer db.RollbackTx(writeTx)
+		_, err = db.Exec(iinfra.ContextWithTx(ctx, writeTx), "INSERT INTO users (email) VALUES (?)", "fake@email.com")
+		require.Nil(t, err)
+
+		readCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		readTx, err := db.BeginTx(readCtx, &sql.TxOptions{ReadOnly: true})
+		require.Nil(t, err)
+		defer db.RollbackTx(readTx)
+
+		// and the reader sees the data committed before it
+		rows, err := db.Query(iinfra.ContextWithTx(readCtx, readTx), "SELECT COUNT(*) FROM users")
+		require.Nil(t, err)
+		defer rows.Close()
+		require.True(t, rows.Next())
+		var count int
+		require.Nil(t, rows.Scan(&count))
+		assert.Equal(t, 0, count)
+	})
+}
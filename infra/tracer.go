@@ -0,0 +1,247 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// Status codes of the spans, as the OpenTelemetry ones
+const (
+	SpanStatusUnset = "STATUS_CODE_UNSET"
+	SpanStatusError = "STATUS_CODE_ERROR"
+)
+
+// traceFlagSampled is the flag of the traceparent header telling that the trace is recorded
+const traceFlagSampled = 0x01
+
+// traceParent matches the W3C traceparent header, the versions after 00 can add fields to its end
+var traceParent = regexp.MustCompile(`^([0-9a-f]{2})-([0-9a-f]{32})-([0-9a-f]{16})-([0-9a-f]{2})(-.*)?$`)
+
+type (
+	// SpanExporter sends the ended spans to where they are kept, ex: a file or a collector
+	SpanExporter interface {
+		Export(span SpanData) error
+	}
+
+	// SpanData is an ended span, its JSON follows the OpenTelemetry protocol
+	SpanData struct {
+		TraceID           string            `json:"traceId"`
+		SpanID            string            `json:"spanId"`
+		ParentSpanID      string            `json:"parentSpanId,omitempty"`
+		Name              string            `json:"name"`
+		StartTimeUnixNano int64             `json:"startTimeUnixNano"`
+		EndTimeUnixNano   int64             `json:"endTimeUnixNano"`
+		Attributes        iinfra.SpanAttrs  `json:"attributes,omitempty"`
+		Status            SpanStatus        `json:"status"`
+		Resource          map[string]string `json:"resource"` // the service.name of the application
+	}
+
+	// SpanStatus ...
+	SpanStatus struct {
+		Code    string `json:"code"`
+		Message string `json:"message,omitempty"`
+	}
+
+	tracer struct {
+		service  string
+		exporter SpanExporter
+		logger   iinfra.LogProvider
+	}
+
+	// spanContext identifies a span in its trace, it's what is propagated to the children
+	spanContext struct {
+		traceID string
+		spanID  string
+		sampled bool
+	}
+
+	spanContextKey struct{}
+
+	span struct {
+		tracer  tracer
+		context spanContext
+
+		mu    sync.Mutex
+		data  SpanData
+		ended bool
+	}
+
+	writerExporter struct {
+		mu  sync.Mutex
+		enc *json.Encoder
+	}
+)
+
+// NewTracer exports the spans of the service with the exporter, a nil exporter drops them
+func NewTracer(service string, exporter SpanExporter, logger iinfra.LogProvider) iinfra.Tracer {
+	return tracer{
+		service:  service,
+		exporter: exporter,
+		logger:   logger,
+	}
+}
+
+// NewWriterExporter writes each span to w as a JSON line
+func NewWriterExporter(w io.Writer) SpanExporter {
+	return &writerExporter{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// NewSpanExporter creates the exporter of the config, closeFn releases the file of the file exporter. The
+// none exporter is nil
+func NewSpanExporter(cfg TraceConfig) (exporter SpanExporter, closeFn func() error, err error) {
+	switch cfg.Exporter {
+	case TraceExporterStdout:
+		return NewWriterExporter(os.Stdout), func() error { return nil }, nil
+	case TraceExporterFile:
+		var f *os.File
+		f, err = os.OpenFile(cfg.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		return NewWriterExporter(f), f.Close, nil
+	}
+
+	return nil, func() error { return nil }, nil
+}
+
+// Start ...
+func (t tracer) Start(ctx context.Context, name string, attrs iinfra.SpanAttrs) (context.Context, iinfra.Span) {
+	parent, _ := ctx.Value(spanContextKey{}).(spanContext)
+
+	s := &span{
+		tracer: t,
+		context: spanContext{
+			traceID: parent.traceID,
+			spanID:  newTraceID(8),
+			sampled: parent.sampled,
+		},
+	}
+	if parent.traceID == "" {
+		// a root span, the new traces are always recorded
+		s.context.traceID = newTraceID(16)
+		s.context.sampled = true
+	}
+
+	s.data = SpanData{
+		TraceID:           s.context.traceID,
+		SpanID:            s.context.spanID,
+		ParentSpanID:      parent.spanID,
+		Name:              name,
+		StartTimeUnixNano: time.Now().UnixNano(),
+		Attributes:        make(iinfra.SpanAttrs, len(attrs)),
+		Status:            SpanStatus{Code: SpanStatusUnset},
+		Resource:          map[string]string{"service.name": t.service},
+	}
+	for key, value := range attrs {
+		s.data.Attributes[key] = value
+	}
+
+	return context.WithValue(ctx, spanContextKey{}, s.context), s
+}
+
+// Extract ...
+func (t tracer) Extract(ctx context.Context, traceparent string) context.Context {
+	parent, ok := parseTraceParent(traceparent)
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, spanContextKey{}, parent)
+}
+
+// SetAttrs ...
+func (s *span) SetAttrs(attrs iinfra.SpanAttrs) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, value := range attrs {
+		s.data.Attributes[key] = value
+	}
+}
+
+// RecordError ...
+func (s *span) RecordError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Status = SpanStatus{Code: SpanStatusError, Message: err.Error()}
+}
+
+// End ...
+func (s *span) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTimeUnixNano = time.Now().UnixNano()
+	data := s.data
+	s.mu.Unlock()
+
+	if s.tracer.exporter == nil || !s.context.sampled {
+		return
+	}
+	if err := s.tracer.exporter.Export(data); err != nil {
+		s.tracer.logger.Error(context.Background(), fmt.Sprintf("error when exporting span %s: %v", data.Name, err))
+	}
+}
+
+// TraceID ...
+func (s *span) TraceID() string {
+	return s.context.traceID
+}
+
+// Export ...
+func (w *writerExporter) Export(span SpanData) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.enc.Encode(span)
+}
+
+// parseTraceParent reads the span context of the traceparent header, see
+// https://www.w3.org/TR/trace-context/#traceparent-header
+func parseTraceParent(header string) (parent spanContext, ok bool) {
+	m := traceParent.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return
+	}
+	version, traceID, spanID, flags, rest := m[1], m[2], m[3], m[4], m[5]
+
+	// ff is an invalid version and the version 00 has no other field
+	if version == "ff" || (version == "00" && rest != "") {
+		return
+	}
+	// the zero IDs are invalid too
+	if strings.Trim(traceID, "0") == "" || strings.Trim(spanID, "0") == "" {
+		return
+	}
+
+	b, _ := hex.DecodeString(flags)
+	return spanContext{
+		traceID: traceID,
+		spanID:  spanID,
+		sampled: b[0]&traceFlagSampled != 0,
+	}, true
+}
+
+// newTraceID is a random hex ID of n bytes, the trace IDs have 16 bytes and the span IDs 8
+func newTraceID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
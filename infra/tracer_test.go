@@ -0,0 +1,147 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeExporter keeps the spans in memory
+type fakeExporter struct {
+	spans []SpanData
+	err   error
+}
+
+func (f *fakeExporter) Export(span SpanData) error {
+	f.spans = append(f.spans, span)
+	return f.err
+}
+
+func TestTracer(t *testing.T) {
+	logger, err := NewLogrus("panic", LogFormatJSON)
+	require.NoError(t, err)
+
+	t.Run("should start a new trace and put the children spans in it", func(t *testing.T) {
+		exporter := &fakeExporter{}
+		tracer := NewTracer("user-api", exporter, logger)
+
+		ctx, root := tracer.Start(context.Background(), "root", iinfra.SpanAttrs{"http.method": "GET"})
+		_, child := tracer.Start(ctx, "child", nil)
+		child.SetAttrs(iinfra.SpanAttrs{"outcome": "ok"})
+		child.RecordError(errors.New("fake-error"))
+		child.End()
+		root.End()
+		root.End() // ignored
+
+		require.Len(t, exporter.spans, 2)
+		childData, rootData := exporter.spans[0], exporter.spans[1]
+
+		assert.Len(t, rootData.TraceID, 32)
+		assert.Len(t, rootData.SpanID, 16)
+		assert.Empty(t, rootData.ParentSpanID)
+		assert.Equal(t, root.TraceID(), rootData.TraceID)
+		assert.Equal(t, iinfra.SpanAttrs{"http.method": "GET"}, rootData.Attributes)
+		assert.Equal(t, SpanStatus{Code: SpanStatusUnset}, rootData.Status)
+		assert.Equal(t, map[string]string{"service.name": "user-api"}, rootData.Resource)
+		assert.LessOrEqual(t, rootData.StartTimeUnixNano, rootData.EndTimeUnixNano)
+
+		assert.Equal(t, rootData.TraceID, childData.TraceID)
+		assert.Equal(t, rootData.SpanID, childData.ParentSpanID)
+		assert.NotEqual(t, rootData.SpanID, childData.SpanID)
+		assert.Equal(t, iinfra.SpanAttrs{"outcome": "ok"}, childData.Attributes)
+		assert.Equal(t, SpanStatus{Code: SpanStatusError, Message: "fake-error"}, childData.Status)
+	})
+
+	t.Run("should continue the trace of the traceparent header", func(t *testing.T) {
+		exporter := &fakeExporter{}
+		tracer := NewTracer("user-api", exporter, logger)
+
+		ctx := tracer.Extract(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		_, span := tracer.Start(ctx, "span", nil)
+		span.End()
+
+		require.Len(t, exporter.spans, 1)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", exporter.spans[0].TraceID)
+		assert.Equal(t, "00f067aa0ba902b7", exporter.spans[0].ParentSpanID)
+	})
+
+	t.Run("should not export the spans of the traces that aren't sampled", func(t *testing.T) {
+		exporter := &fakeExporter{}
+		tracer := NewTracer("user-api", exporter, logger)
+
+		ctx := tracer.Extract(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00")
+		_, span := tracer.Start(ctx, "span", nil)
+		span.End()
+
+		assert.Empty(t, exporter.spans)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.TraceID())
+	})
+
+	t.Run("should drop the spans when there is no exporter", func(t *testing.T) {
+		_, span := NewTracer("user-api", nil, logger).Start(context.Background(), "span", nil)
+		assert.NotPanics(t, span.End)
+	})
+}
+
+func TestParseTraceParent(t *testing.T) {
+	t.Run("should read the valid headers only", func(t *testing.T) {
+		for header, expected := range map[string]bool{
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       true,
+			"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": true, // a future version
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra": false,
+			"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":       false,
+			"00-00000000000000000000000000000000-00f067aa0ba902b7-01":       false,
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":       false,
+			"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":       false,
+			"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":          false,
+			"": false,
+		} {
+			_, ok := parseTraceParent(header)
+			assert.Equal(t, expected, ok, header)
+		}
+	})
+}
+
+func TestNewSpanExporter(t *testing.T) {
+	t.Run("should append the spans to the file as JSON lines", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "spans.json")
+		exporter, closeFn, err := NewSpanExporter(TraceConfig{Exporter: TraceExporterFile, File: file})
+		require.NoError(t, err)
+
+		require.NoError(t, exporter.Export(SpanData{TraceID: "fake-trace", Name: "first"}))
+		require.NoError(t, exporter.Export(SpanData{TraceID: "fake-trace", Name: "second"}))
+		require.NoError(t, closeFn())
+
+		b, err := ioutil.ReadFile(file)
+		require.NoError(t, err)
+
+		var names []string
+		dec := json.NewDecoder(bytes.NewReader(b))
+		for dec.More() {
+			var span SpanData
+			require.NoError(t, dec.Decode(&span))
+			names = append(names, span.Name)
+		}
+		assert.Equal(t, []string{"first", "second"}, names)
+		assert.Equal(t, 2, bytes.Count(b, []byte("\n")))
+	})
+
+	t.Run("should have no exporter when none", func(t *testing.T) {
+		exporter, closeFn, err := NewSpanExporter(TraceConfig{Exporter: TraceExporterNone})
+		require.NoError(t, err)
+		assert.Nil(t, exporter)
+		assert.NoError(t, closeFn())
+	})
+}
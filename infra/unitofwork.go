@@ -0,0 +1,100 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// unitOfWorkKey keeps the transaction of the outermost call in the context
+type unitOfWorkKey struct{}
+
+type (
+	unitOfWork struct {
+		session iinfra.Session
+	}
+
+	// unitOfWorkTx is the transaction shared by the nested calls
+	unitOfWorkTx struct {
+		tx         iinfra.Tx
+		savepoints int // used to name the savepoints uniquely in the transaction
+	}
+)
+
+// NewUnitOfWork makes the transactions with the session. If the session isn't an iinfra.Savepointer,
+// a nested call joins the outer transaction and its error rolls back the whole transaction
+func NewUnitOfWork(session iinfra.Session) iinfra.UnitOfWork {
+	return unitOfWork{
+		session: session,
+	}
+}
+
+// WithinTx ...
+func (u unitOfWork) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return u.WithinTxOptions(ctx, nil, fn)
+}
+
+// WithinTxOptions ...
+func (u unitOfWork) WithinTxOptions(ctx context.Context, opts *sql.TxOptions,
+	fn func(ctx context.Context) error) error {
+	if outer, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWorkTx); ok {
+		return u.nested(ctx, outer, fn)
+	}
+
+	tx, err := u.session.BeginTx(ctx, opts)
+	if err != nil {
+		return err
+	}
+
+	ctx = context.WithValue(ctx, unitOfWorkKey{}, &unitOfWorkTx{tx: tx})
+	ctx = iinfra.ContextWithTx(ctx, tx) // add Tx to context to be use in gateways
+
+	defer func() {
+		// the tx must not be left open, but the panic is not ours to handle
+		if p := recover(); p != nil {
+			_ = u.session.RollbackTx(tx)
+			panic(p)
+		}
+	}()
+
+	if err = fn(ctx); err != nil {
+		_ = u.session.RollbackTx(tx)
+		return err
+	}
+
+	return u.session.CommitTx(tx)
+}
+
+// nested runs fn in a savepoint of the outer transaction, so its error only undoes its own changes
+func (u unitOfWork) nested(ctx context.Context, outer *unitOfWorkTx, fn func(ctx context.Context) error) error {
+	sp, ok := u.session.(iinfra.Savepointer)
+	if !ok {
+		return fn(ctx)
+	}
+
+	outer.savepoints++
+	name := fmt.Sprintf("uow_%d", outer.savepoints)
+	if err := sp.Savepoint(ctx, outer.tx, name); err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sp.RollbackToSavepoint(ctx, outer.tx, name)
+			panic(p)
+		}
+	}()
+
+	if err := fn(ctx); err != nil {
+		_ = sp.RollbackToSavepoint(ctx, outer.tx, name)
+		return err
+	}
+
+	return sp.ReleaseSavepoint(ctx, outer.tx, name)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package infra
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnitOfWork(t *testing.T) {
+	fakeError := errors.New("fake-error")
+
+	newUnitOfWork := func(t *testing.T) (iinfra.UnitOfWork, sqlmock.Sqlmock) {
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return NewUnitOfWork(sqlDatabase{db: db, dialect: iinfra.DialectPostgres}), mock
+	}
+
+	t.Run("should commit the tx when the function succeeds", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := uow.WithinTx(context.Background(), func(ctx context.Context) error {
+			tx, ok := iinfra.TxFromContext(ctx)
+			require.True(t, ok)
+			_, err := tx.(*sql.Tx).Exec("DELETE FROM users")
+			return err
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return the error of the commit", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(fakeError)
+
+		err := uow.WithinTx(context.Background(), func(context.Context) error { return nil })
+
+		assert.Equal(t, fakeError, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should not run the function when the tx can't be started", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin().WillReturnError(fakeError)
+
+		err := uow.WithinTx(context.Background(), func(context.Context) error {
+			t.Fatal("the function must not run")
+			return nil
+		})
+
+		assert.Equal(t, fakeError, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rollback the tx and return the error of the function", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := uow.WithinTx(context.Background(), func(context.Context) error { return fakeError })
+
+		assert.Equal(t, fakeError, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rollback the tx and panic again when the function panics", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		assert.PanicsWithValue(t, "fake-panic", func() {
+			_ = uow.WithinTx(context.Background(), func(context.Context) error { panic("fake-panic") })
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should run the nested calls in savepoints of the outer tx", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT uow_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT uow_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("SAVEPOINT uow_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("RELEASE SAVEPOINT uow_2").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		err := uow.WithinTx(context.Background(), func(ctx context.Context) error {
+			// the failure of a nested call doesn't undo the work of the outer one
+			err := uow.WithinTx(ctx, func(context.Context) error { return fakeError })
+			assert.Equal(t, fakeError, err)
+
+			return uow.WithinTx(ctx, func(context.Context) error { return nil })
+		})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rollback to the savepoint when a nested call panics", func(t *testing.T) {
+		uow, mock := newUnitOfWork(t)
+		mock.ExpectBegin()
+		mock.ExpectExec("SAVEPOINT uow_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ROLLBACK TO SAVEPOINT uow_1").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		assert.PanicsWithValue(t, "fake-panic", func() {
+			_ = uow.WithinTx(context.Background(), func(ctx context.Context) error {
+				return uow.WithinTx(ctx, func(context.Context) error { panic("fake-panic") })
+			})
+		})
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should join the outer tx when the session can't make savepoints", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		// the embedding hides every method but the ones of the session
+		uow := NewUnitOfWork(struct{ iinfra.Session }{sqlDatabase{db: db, dialect: iinfra.DialectPostgres}})
+		err = uow.WithinTx(context.Background(), func(ctx context.Context) error {
+			return uow.WithinTx(ctx, func(context.Context) error { return fakeError })
+		})
+
+		assert.Equal(t, fakeError, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/infra"
+	"github.com/dougefr/go-clean-arch/interface/gateway"
+	"github.com/dougefr/go-clean-arch/interface/gateway/gatewaytest"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUserGatewayConformance(t *testing.T) {
+	gatewaytest.RunUserSuite(t, func(t *testing.T) (igateway.User, iinfra.Session) {
+		store := gateway.NewMemoryStore()
+		return gateway.NewMemoryUserGateway(store), store
+	})
+}
+
+func TestSQLite3UserGatewayConformance(t *testing.T) {
+	gatewaytest.RunUserSuite(t, func(t *testing.T) (igateway.User, iinfra.Session) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		return migratedUserGateway(t, db)
+	})
+}
+
+// the servers are external, so they only run when the DSN of a disposable database is given
+func TestPostgresUserGatewayConformance(t *testing.T) {
+	testExternalDatabase(t, iinfra.DialectPostgres, "TEST_POSTGRES_DSN")
+}
+
+func TestMySQLUserGatewayConformance(t *testing.T) {
+	testExternalDatabase(t, iinfra.DialectMySQL, "TEST_MYSQL_DSN")
+}
+
+func testExternalDatabase(t *testing.T, dialect iinfra.Dialect, env string) {
+	dsn := os.Getenv(env)
+	if dsn == "" {
+		t.Skipf("%s not set", env)
+	}
+
+	gatewaytest.RunUserSuite(t, func(t *testing.T) (igateway.User, iinfra.Session) {
+		db, err := infra.NewDatabase(infra.DatabaseConfig{Dialect: dialect, DSN: dsn})
+		require.NoError(t, err)
+
+		// every test starts from an empty schema
+		migrator := gateway.NewMigrator(db, newLogger(t), entity.EmailRules{})
+		require.NoError(t, migrator.Down(context.Background(), 1<<10))
+		return migratedUserGateway(t, db)
+	})
+}
+
+func migratedUserGateway(t *testing.T, db iinfra.Database) (igateway.User, iinfra.Session) {
+	logger := newLogger(t)
+	require.NoError(t, gateway.NewMigrator(db, logger, entity.EmailRules{}).Up(context.Background()))
+	return gateway.NewUserGateway(db, logger, infra.NewPrometheus(), infra.NewTracer("test", nil, logger)), db
+}
+
+func newLogger(t *testing.T) iinfra.LogProvider {
+	logger, err := infra.NewLogrus("panic", infra.LogFormatJSON)
+	require.NoError(t, err)
+	return logger
+}
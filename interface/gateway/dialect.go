@@ -0,0 +1,39 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// rebind translates the ? placeholders used by the gateways to the bind vars of the dialect,
+// leaving the ones inside quoted literals untouched
+func rebind(dialect iinfra.Dialect, query string) string {
+	if dialect != iinfra.DialectPostgres {
+		return query
+	}
+
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+
+	n, quoted := 0, false
+	for _, r := range query {
+		switch {
+		case r == '\'':
+			quoted = !quoted
+		case r == '?' && !quoted:
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
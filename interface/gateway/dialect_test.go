@@ -0,0 +1,25 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRebind(t *testing.T) {
+	const query = `SELECT id FROM users WHERE name LIKE ? ESCAPE '\' AND email = '?' AND id IN (?, ?)`
+
+	t.Run("should keep the placeholders of sqlite3", func(t *testing.T) {
+		assert.Equal(t, query, rebind(iinfra.DialectSQLite3, query))
+	})
+
+	t.Run("should number the placeholders of postgres, except the quoted ones", func(t *testing.T) {
+		assert.Equal(t, `SELECT id FROM users WHERE name LIKE $1 ESCAPE '\' AND email = '?' AND id IN ($2, $3)`,
+			rebind(iinfra.DialectPostgres, query))
+	})
+}
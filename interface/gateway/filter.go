@@ -0,0 +1,66 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"strings"
+
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// escapes the LIKE wildcards, so they are matched literally. The escape char isn't a backslash
+// because MySQL reads '\' as an escaped quote
+var likeEscaper = strings.NewReplacer(`!`, `!!`, `%`, `!%`, `_`, `!_`)
+
+// filterQuery appends the WHERE clause of the filter to the query, all conditions are combined with AND.
+// Deleted users are always excluded.
+func filterQuery(query string, filter igateway.UserFilter) (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	var args []interface{}
+
+	if filter.Email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, filter.Email)
+	}
+
+	if filter.NameContains != "" {
+		conditions = append(conditions, `name_folded LIKE ? ESCAPE '!'`)
+		args = append(args, "%"+likeEscaper.Replace(foldName(filter.NameContains))+"%")
+	}
+
+	if filter.EmailDomain != "" {
+		conditions = append(conditions, `LOWER(email) LIKE ? ESCAPE '!'`)
+		args = append(args, "%@"+likeEscaper.Replace(strings.ToLower(filter.EmailDomain)))
+	}
+
+	if len(filter.IDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.IDs)), ", ")
+		conditions = append(conditions, "id IN ("+placeholders+")")
+		for _, id := range filter.IDs {
+			args = append(args, id)
+		}
+	}
+
+	if !filter.CreatedFrom.IsZero() {
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, filter.CreatedFrom.UTC())
+	}
+
+	if !filter.CreatedTo.IsZero() {
+		conditions = append(conditions, "created_at < ?")
+		args = append(args, filter.CreatedTo.UTC())
+	}
+
+	return query + " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// foldName is the case folded form of the name matched by the name filter, the one kept at the
+// name_folded column. The fold is done here, instead of by the database, so every dialect matches the
+// same names, ex: Émile and ÉMILE
+func foldName(name string) string {
+	return norm.NFC.String(cases.Fold().String(norm.NFC.String(name)))
+}
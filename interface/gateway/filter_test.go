@@ -0,0 +1,45 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterQuery(t *testing.T) {
+	const query = "SELECT id FROM users"
+
+	t.Run("should only exclude deleted users when the filter is empty", func(t *testing.T) {
+		q, args := filterQuery(query, igateway.UserFilter{})
+		assert.Equal(t, query+" WHERE deleted_at IS NULL", q)
+		assert.Empty(t, args)
+	})
+
+	t.Run("should combine all filters with AND", func(t *testing.T) {
+		from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+
+		q, args := filterQuery(query, igateway.UserFilter{
+			Email:        "fake@acme.com",
+			NameContains: "Fake",
+			EmailDomain:  "ACME.com",
+			IDs:          []int64{1, 2},
+			CreatedFrom:  from,
+			CreatedTo:    to,
+		})
+		assert.Equal(t, query+` WHERE deleted_at IS NULL AND email = ? AND name_folded LIKE ? ESCAPE '!'`+
+			` AND LOWER(email) LIKE ? ESCAPE '!' AND id IN (?, ?) AND created_at >= ? AND created_at < ?`, q)
+		assert.Equal(t, []interface{}{"fake@acme.com", "%fake%", "%@acme.com", int64(1), int64(2), from, to}, args)
+	})
+
+	t.Run("should match LIKE wildcards literally", func(t *testing.T) {
+		_, args := filterQuery(query, igateway.UserFilter{NameContains: `50%_off!`})
+		assert.Equal(t, []interface{}{`%50!%!_off!!%`}, args)
+	})
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+// Package gatewaytest holds the conformance suites that every gateway implementation must pass
+package gatewaytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// UserFactory returns a gateway with no users and the session its transactions are made by
+type UserFactory func(t *testing.T) (igateway.User, iinfra.Session)
+
+// RunUserSuite checks that the igateway.User built by newGateway honors the contract the
+// interactors rely on. Every test gets a new gateway
+func RunUserSuite(t *testing.T, newGateway UserFactory) {
+	ctx := context.Background()
+
+	t.Run("should create users with distinct IDs and find them", func(t *testing.T) {
+		g, _ := newGateway(t)
+		before := time.Now().Add(-time.Second)
+
+		first := mustCreate(t, g, "first name", "first@email.com")
+		second := mustCreate(t, g, "second name", "second@email.com")
+		assert.NotZero(t, first.ID)
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.Equal(t, "first name", first.Name)
+		assert.Equal(t, "first@email.com", first.Email)
+		assert.Equal(t, "FIRST@EMAIL.COM", first.DisplayEmail)
+		assert.True(t, first.CreatedAt.After(before), first.CreatedAt)
+
+		found, err := g.FindByID(ctx, first.ID)
+		assert.NoError(t, err)
+		assertSameUser(t, first, found)
+
+		found, err = g.FindByEmail(ctx, second.Email)
+		assert.NoError(t, err)
+		assertSameUser(t, second, found)
+	})
+
+	t.Run("should return ErrCreateUserNotFound when the user does not exist", func(t *testing.T) {
+		g, _ := newGateway(t)
+		const unknownID = int64(999999)
+
+		_, err := g.FindByID(ctx, unknownID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		_, err = g.FindDeletedByID(ctx, unknownID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		_, err = g.FindByEmail(ctx, "unknown@email.com")
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		_, err = g.Update(ctx, entity.User{ID: unknownID, Name: "name", Email: "unknown@email.com"})
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, g.Delete(ctx, unknownID))
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, g.Purge(ctx, unknownID))
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, g.Restore(ctx, unknownID))
+	})
+
+	t.Run("should refuse two active users with the same email", func(t *testing.T) {
+		g, _ := newGateway(t)
+		user := mustCreate(t, g, "fake name", "fake@email.com")
+
+		_, err := g.Create(ctx, entity.User{Name: "another name", Email: user.Email})
+		assert.Equal(t, businesserr.ErrCreateUserAlreadyExists, err)
+
+		other := mustCreate(t, g, "other name", "other@email.com")
+		_, err = g.Update(ctx, entity.User{ID: other.ID, Name: other.Name, Email: user.Email})
+		assert.Equal(t, businesserr.ErrCreateUserAlreadyExists, err)
+
+		// deleted users don't hold their emails, but can't be restored while another user has it
+		require.NoError(t, g.Delete(ctx, user.ID))
+		mustCreate(t, g, "another name", user.Email)
+		assert.Equal(t, businesserr.ErrCreateUserAlreadyExists, g.Restore(ctx, user.ID))
+	})
+
+	t.Run("should let only one of the concurrent creations take the email", func(t *testing.T) {
+		g, _ := newGateway(t)
+		const creations = 10
+
+		var wg sync.WaitGroup
+		errs := make(chan error, creations)
+		for i := 0; i < creations; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, err := g.Create(ctx, entity.User{Name: fmt.Sprintf("name %d", i), Email: "race@email.com"})
+				errs <- err
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			if err == nil {
+				created++
+				continue
+			}
+			assert.Equal(t, businesserr.ErrCreateUserAlreadyExists, err)
+		}
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("should let only one of the concurrent transactions that check the email take it", func(t *testing.T) {
+		g, session := newGateway(t)
+		const creations = 10
+
+		// each transaction does what the create user interactor does
+		create := func(i int) error {
+			tx, err := session.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			txCtx := iinfra.ContextWithTx(ctx, tx)
+
+			_, err = g.FindByEmail(txCtx, "race@email.com")
+			if err == nil {
+				err = businesserr.ErrCreateUserAlreadyExists
+			} else if errors.Is(err, businesserr.ErrCreateUserNotFound) {
+				_, err = g.Create(txCtx, entity.User{Name: fmt.Sprintf("name %d", i), Email: "race@email.com"})
+			}
+			if err != nil {
+				_ = session.RollbackTx(tx)
+				return err
+			}
+			return session.CommitTx(tx)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, creations)
+		for i := 0; i < creations; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs <- create(i)
+			}(i)
+		}
+		wg.Wait()
+		close(errs)
+
+		created := 0
+		for err := range errs {
+			if err == nil {
+				created++
+				continue
+			}
+			assert.Equal(t, businesserr.ErrCreateUserAlreadyExists, err)
+		}
+		assert.Equal(t, 1, created)
+	})
+
+	t.Run("should update the user data", func(t *testing.T) {
+		g, _ := newGateway(t)
+		user := mustCreate(t, g, "fake name", "fake@email.com")
+
+		user.Name, user.Email, user.DisplayEmail = "new name", "new@email.com", "New@Email.com"
+		updated, err := g.Update(ctx, user)
+		assert.NoError(t, err)
+		assertSameUser(t, user, updated)
+
+		found, err := g.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assertSameUser(t, user, found)
+	})
+
+	t.Run("should delete, restore and purge the users", func(t *testing.T) {
+		g, _ := newGateway(t)
+		user := mustCreate(t, g, "fake name", "fake@email.com")
+
+		require.NoError(t, g.Delete(ctx, user.ID))
+		_, err := g.FindByID(ctx, user.ID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		deleted, err := g.FindDeletedByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assertSameUser(t, user, deleted)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, g.Delete(ctx, user.ID))
+
+		require.NoError(t, g.Restore(ctx, user.ID))
+		_, err = g.FindByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, g.Restore(ctx, user.ID))
+
+		require.NoError(t, g.Purge(ctx, user.ID))
+		_, err = g.FindByID(ctx, user.ID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		_, err = g.FindDeletedByID(ctx, user.ID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+	})
+
+	t.Run("should filter and count the active users", func(t *testing.T) {
+		g, _ := newGateway(t)
+		alice := mustCreate(t, g, "Alice Smith", "alice@one.com")
+		bob := mustCreate(t, g, "Bob Smith", "bob@two.com")
+		carol := mustCreate(t, g, "Carol 100%_off", "carol@one.com")
+		deleted := mustCreate(t, g, "Dave Smith", "dave@one.com")
+		require.NoError(t, g.Delete(ctx, deleted.ID))
+		emile := mustCreate(t, g, "Émile Zola", "emile@three.com")
+
+		for name, test := range map[string]struct {
+			filter igateway.UserFilter
+			ids    []int64
+		}{
+			"no filter":           {igateway.UserFilter{}, []int64{alice.ID, bob.ID, carol.ID, emile.ID}},
+			"email":               {igateway.UserFilter{Email: bob.Email}, []int64{bob.ID}},
+			"name contains":       {igateway.UserFilter{NameContains: "SMITH"}, []int64{alice.ID, bob.ID}},
+			"non-ASCII name":      {igateway.UserFilter{NameContains: "ÉMILE"}, []int64{emile.ID}},
+			"decomposed name":     {igateway.UserFilter{NameContains: "e\u0301mile"}, []int64{emile.ID}},
+			"wildcards in name":   {igateway.UserFilter{NameContains: "%_"}, []int64{carol.ID}},
+			"email domain":        {igateway.UserFilter{EmailDomain: "ONE.com"}, []int64{alice.ID, carol.ID}},
+			"ids":                 {igateway.UserFilter{IDs: []int64{bob.ID, deleted.ID}}, []int64{bob.ID}},
+			"created in the past": {igateway.UserFilter{CreatedTo: time.Now().Add(-time.Hour)}, nil},
+			"all filters": {igateway.UserFilter{
+				NameContains: "smith",
+				EmailDomain:  "one.com",
+				IDs:          []int64{alice.ID, bob.ID},
+				CreatedFrom:  time.Now().Add(-time.Hour),
+				CreatedTo:    time.Now().Add(time.Hour),
+			}, []int64{alice.ID}},
+		} {
+			users, err := g.FindAll(ctx, test.filter, igateway.UserPage{})
+			assert.NoError(t, err, name)
+			assert.Equal(t, test.ids, userIDs(users), name)
+
+			total, err := g.Count(ctx, test.filter)
+			assert.NoError(t, err, name)
+			assert.Equal(t, int64(len(test.ids)), total, name)
+		}
+
+		// the name matched is the updated one
+		emile.Name = "Ölaf Zola"
+		_, err := g.Update(ctx, emile)
+		require.NoError(t, err)
+		users, err := g.FindAll(ctx, igateway.UserFilter{NameContains: "ölaf"}, igateway.UserPage{})
+		assert.NoError(t, err)
+		assert.Equal(t, []int64{emile.ID}, userIDs(users))
+	})
+
+	t.Run("should sort and paginate the users", func(t *testing.T) {
+		g, _ := newGateway(t)
+		carol := mustCreate(t, g, "Carol", "a@email.com")
+		alice := mustCreate(t, g, "Alice", "c@email.com")
+		bob1 := mustCreate(t, g, "Bob", "d@email.com")
+		bob2 := mustCreate(t, g, "Bob", "b@email.com")
+
+		for name, test := range map[string]struct {
+			page igateway.UserPage
+			ids  []int64
+		}{
+			"by id":         {igateway.UserPage{}, []int64{carol.ID, alice.ID, bob1.ID, bob2.ID}},
+			"by id desc":    {igateway.UserPage{SortDesc: true}, []int64{bob2.ID, bob1.ID, alice.ID, carol.ID}},
+			"by email":      {igateway.UserPage{SortField: igateway.UserSortByEmail}, []int64{carol.ID, bob2.ID, alice.ID, bob1.ID}},
+			"by name":       {igateway.UserPage{SortField: igateway.UserSortByName}, []int64{alice.ID, bob1.ID, bob2.ID, carol.ID}},
+			"by name desc":  {igateway.UserPage{SortField: igateway.UserSortByName, SortDesc: true}, []int64{carol.ID, bob2.ID, bob1.ID, alice.ID}},
+			"limited":       {igateway.UserPage{Limit: 2}, []int64{carol.ID, alice.ID}},
+			"after id":      {igateway.UserPage{After: &igateway.UserCursor{ID: alice.ID}}, []int64{bob1.ID, bob2.ID}},
+			"after id desc": {igateway.UserPage{After: &igateway.UserCursor{ID: bob1.ID}, SortDesc: true}, []int64{alice.ID, carol.ID}},
+			"after a tie": {igateway.UserPage{
+				After:     &igateway.UserCursor{ID: bob1.ID, Value: "Bob"},
+				SortField: igateway.UserSortByName,
+			}, []int64{bob2.ID, carol.ID}},
+			"after a tie desc": {igateway.UserPage{
+				Limit:     1,
+				After:     &igateway.UserCursor{ID: bob2.ID, Value: "Bob"},
+				SortField: igateway.UserSortByName,
+				SortDesc:  true,
+			}, []int64{bob1.ID}},
+		} {
+			users, err := g.FindAll(ctx, igateway.UserFilter{}, test.page)
+			assert.NoError(t, err, name)
+			assert.Equal(t, test.ids, userIDs(users), name)
+		}
+	})
+
+	t.Run("should only keep the changes of committed transactions", func(t *testing.T) {
+		g, session := newGateway(t)
+
+		tx, err := session.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		txCtx := iinfra.ContextWithTx(ctx, tx)
+		discarded, err := g.Create(txCtx, entity.User{Name: "discarded", Email: "discarded@email.com"})
+		require.NoError(t, err)
+
+		// the transaction sees its own changes
+		_, err = g.FindByID(txCtx, discarded.ID)
+		assert.NoError(t, err)
+		require.NoError(t, session.RollbackTx(tx))
+
+		_, err = g.FindByID(ctx, discarded.ID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		_, err = g.FindByEmail(ctx, discarded.Email)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+
+		tx, err = session.BeginTx(ctx, nil)
+		require.NoError(t, err)
+		txCtx = iinfra.ContextWithTx(ctx, tx)
+		kept, err := g.Create(txCtx, entity.User{Name: "kept", Email: "kept@email.com"})
+		require.NoError(t, err)
+		require.NoError(t, session.CommitTx(tx))
+
+		found, err := g.FindByID(ctx, kept.ID)
+		assert.NoError(t, err)
+		assertSameUser(t, kept, found)
+	})
+}
+
+// mustCreate creates the user with a display email that differs from the canonical one
+func mustCreate(t *testing.T, g igateway.User, name, email string) entity.User {
+	user, err := g.Create(context.Background(), entity.User{
+		Name:         name,
+		Email:        email,
+		DisplayEmail: strings.ToUpper(email),
+	})
+	require.NoError(t, err)
+	return user
+}
+
+// assertSameUser compares the users ignoring the precision and location of the creation time,
+// which vary between databases
+func assertSameUser(t *testing.T, expected, actual entity.User) {
+	assert.WithinDuration(t, expected.CreatedAt, actual.CreatedAt, time.Millisecond)
+	expected.CreatedAt, actual.CreatedAt = time.Time{}, time.Time{}
+	assert.Equal(t, expected, actual)
+}
+
+func userIDs(users []entity.User) (ids []int64) {
+	for _, user := range users {
+		ids = append(ids, user.ID)
+	}
+	return
+}
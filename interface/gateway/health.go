@@ -0,0 +1,55 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+type migrationChecker struct {
+	migrator Migrator
+}
+
+// NewMigrationChecker checks if the database schema is in the version expected by the gateways, which is
+// the one of the last known migration
+func NewMigrationChecker(migrator Migrator) iinfra.HealthChecker {
+	return migrationChecker{
+		migrator: migrator,
+	}
+}
+
+// Name ...
+func (m migrationChecker) Name() string {
+	return "migrations"
+}
+
+// Check ...
+func (m migrationChecker) Check(ctx context.Context) error {
+	migrations, err := m.migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	// the migrations are listed in order, so the expected version is the last one
+	var version, expected int64
+	pending := 0
+	for _, mig := range migrations {
+		expected = mig.Version
+		if mig.AppliedAt == nil {
+			pending++
+		} else {
+			version = mig.Version
+		}
+	}
+
+	if pending > 0 {
+		return fmt.Errorf("schema at version %d, expected %d: %d pending migrations", version, expected, pending)
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMigrator only knows the status of the migrations
+type fakeMigrator struct {
+	Migrator
+	status []MigrationStatus
+	err    error
+}
+
+func (f fakeMigrator) Status(context.Context) ([]MigrationStatus, error) {
+	return f.status, f.err
+}
+
+func TestMigrationChecker(t *testing.T) {
+	appliedAt := time.Now()
+
+	t.Run("should pass when every migration was applied", func(t *testing.T) {
+		checker := NewMigrationChecker(fakeMigrator{status: []MigrationStatus{
+			{Version: 1, AppliedAt: &appliedAt},
+			{Version: 2, AppliedAt: &appliedAt},
+		}})
+
+		assert.Equal(t, "migrations", checker.Name())
+		assert.NoError(t, checker.Check(context.Background()))
+	})
+
+	t.Run("should fail when the schema isn't in the last version", func(t *testing.T) {
+		checker := NewMigrationChecker(fakeMigrator{status: []MigrationStatus{
+			{Version: 1, AppliedAt: &appliedAt},
+			{Version: 2},
+			{Version: 3},
+		}})
+
+		assert.EqualError(t, checker.Check(context.Background()),
+			"schema at version 1, expected 3: 2 pending migrations")
+	})
+
+	t.Run("should fail when the status can't be read", func(t *testing.T) {
+		expectedErr := errors.New("fake-error")
+		checker := NewMigrationChecker(fakeMigrator{err: expectedErr})
+
+		assert.Equal(t, expectedErr, checker.Check(context.Background()))
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+var (
+	// errMemoryTxDone is returned when a transaction is committed or rolled back twice
+	errMemoryTxDone = errors.New("transaction has already been committed or rolled back")
+	// errMemoryTxReadOnly is returned when a read-only transaction tries to change any data
+	errMemoryTxReadOnly = errors.New("transaction is read-only")
+	// errMemoryForeignTx is returned when the transaction of the context wasn't started by the store
+	errMemoryForeignTx = errors.New("the transaction of the context is not one of the store")
+	// errMemorySavepointNotFound is returned when the savepoint was not made in the transaction
+	errMemorySavepointNotFound = errors.New("savepoint does not exist")
+)
+
+type (
+	// MemoryStore keeps the data of the in-memory gateways and is the iinfra.Session of them.
+	// Transactions are serialized: a new one, or a call outside of them, waits for the current to
+	// finish, and its rollback restores every table to the snapshot taken at the beginning
+	MemoryStore struct {
+		txSem  chan struct{} // held from the beginning to the end of a transaction
+		mu     sync.Mutex    // guards the tables
+		tables map[string]memoryTable
+	}
+
+	// memoryTable is the data of an in-memory gateway
+	memoryTable interface {
+		clone() memoryTable
+	}
+
+	memoryTx struct {
+		store      *MemoryStore
+		snapshot   map[string]memoryTable
+		savepoints map[string]map[string]memoryTable // snapshots taken by the savepoints, by name
+		readOnly   bool
+		done       bool
+	}
+)
+
+// NewMemoryStore ...
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		txSem:  make(chan struct{}, 1),
+		tables: make(map[string]memoryTable),
+	}
+}
+
+// BeginTx waits for the current transaction to finish, or for ctx to be done. As transactions
+// are serialized, every isolation level is honored
+func (s *MemoryStore) BeginTx(ctx context.Context, opts *sql.TxOptions) (iinfra.Tx, error) {
+	if err := s.acquire(ctx); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return &memoryTx{
+		store:      s,
+		snapshot:   s.cloneTables(),
+		savepoints: make(map[string]map[string]memoryTable),
+		readOnly:   opts != nil && opts.ReadOnly,
+	}, nil
+}
+
+// CommitTx ...
+func (s *MemoryStore) CommitTx(tx iinfra.Tx) error {
+	return s.endTx(tx, false)
+}
+
+// RollbackTx ...
+func (s *MemoryStore) RollbackTx(tx iinfra.Tx) error {
+	return s.endTx(tx, true)
+}
+
+func (s *MemoryStore) endTx(tx iinfra.Tx, rollback bool) error {
+	t, err := s.activeTx(tx)
+	if t == nil || err != nil {
+		return err
+	}
+	t.done = true
+
+	if rollback {
+		s.mu.Lock()
+		s.tables = t.snapshot
+		s.mu.Unlock()
+	}
+
+	<-s.txSem
+	return nil
+}
+
+// Savepoint ...
+func (s *MemoryStore) Savepoint(_ context.Context, tx iinfra.Tx, name string) error {
+	t, err := s.activeTx(tx)
+	if t == nil || err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.savepoints[name] = s.cloneTables()
+	return nil
+}
+
+// RollbackToSavepoint restores the tables to the savepoint, which is kept to be rolled back to again
+func (s *MemoryStore) RollbackToSavepoint(_ context.Context, tx iinfra.Tx, name string) error {
+	t, err := s.activeTx(tx)
+	if t == nil || err != nil {
+		return err
+	}
+
+	snapshot, ok := t.savepoints[name]
+	if !ok {
+		return errMemorySavepointNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tables = make(map[string]memoryTable, len(snapshot))
+	for name, table := range snapshot {
+		s.tables[name] = table.clone()
+	}
+	return nil
+}
+
+// ReleaseSavepoint ...
+func (s *MemoryStore) ReleaseSavepoint(_ context.Context, tx iinfra.Tx, name string) error {
+	t, err := s.activeTx(tx)
+	if t == nil || err != nil {
+		return err
+	}
+
+	if _, ok := t.savepoints[name]; !ok {
+		return errMemorySavepointNotFound
+	}
+	delete(t.savepoints, name)
+	return nil
+}
+
+// activeTx gets the transaction of the store, that is nil when tx is from another one
+func (s *MemoryStore) activeTx(tx iinfra.Tx) (*memoryTx, error) {
+	t, ok := tx.(*memoryTx)
+	if !ok || t.store != s {
+		return nil, nil
+	}
+	if t.done {
+		return nil, errMemoryTxDone
+	}
+	return t, nil
+}
+
+// acquire waits for the current transaction to finish, unless ctx is done first
+func (s *MemoryStore) acquire(ctx context.Context) error {
+	select {
+	case s.txSem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// do runs fn with the table named name, creating it with empty if needed. Outside of a transaction
+// it waits for the current one to finish, so uncommitted data is never seen
+func (s *MemoryStore) do(ctx context.Context, name string, empty func() memoryTable, write bool,
+	fn func(memoryTable) error) error {
+	if tx, ok := iinfra.TxFromContext(ctx); ok {
+		t, err := s.activeTx(tx)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return errMemoryForeignTx
+		}
+		if write && t.readOnly {
+			return errMemoryTxReadOnly
+		}
+	} else {
+		if iinfra.TxRequired(ctx) {
+			return iinfra.ErrTxRequired
+		}
+		if err := s.acquire(ctx); err != nil {
+			return err
+		}
+		defer func() { <-s.txSem }()
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[name]
+	if !ok {
+		table = empty()
+		s.tables[name] = table
+	}
+
+	return fn(table)
+}
+
+func (s *MemoryStore) cloneTables() map[string]memoryTable {
+	tables := make(map[string]memoryTable, len(s.tables))
+	for name, table := range s.tables {
+		tables[name] = table.clone()
+	}
+	return tables
+}
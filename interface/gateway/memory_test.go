@@ -0,0 +1,196 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	t.Run("should keep the changes of a committed transaction", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+
+		tx, err := store.BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		ctx := iinfra.ContextWithTx(context.Background(), tx)
+		user, err := g.Create(ctx, entity.User{Name: "fake name", Email: "fake@email.com"})
+		require.Nil(t, err)
+		assert.NoError(t, store.CommitTx(tx))
+
+		found, err := g.FindByID(context.Background(), user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user, found)
+	})
+
+	t.Run("should discard the changes of a rolled back transaction", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+		kept, err := g.Create(context.Background(), entity.User{Name: "kept", Email: "kept@email.com"})
+		require.Nil(t, err)
+
+		tx, err := store.BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		ctx := iinfra.ContextWithTx(context.Background(), tx)
+		discarded, err := g.Create(ctx, entity.User{Name: "discarded", Email: "discarded@email.com"})
+		require.Nil(t, err)
+		require.Nil(t, g.Delete(ctx, kept.ID))
+		assert.NoError(t, store.RollbackTx(tx))
+
+		_, err = g.FindByID(context.Background(), discarded.ID)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+		_, err = g.FindByID(context.Background(), kept.ID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error when the transaction has already ended", func(t *testing.T) {
+		store := NewMemoryStore()
+
+		tx, err := store.BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		assert.NoError(t, store.CommitTx(tx))
+		assert.Equal(t, errMemoryTxDone, store.RollbackTx(tx))
+	})
+
+	t.Run("should not see the uncommitted data of another transaction", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+
+		tx, err := store.BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		ctx := iinfra.ContextWithTx(context.Background(), tx)
+		_, err = g.Create(ctx, entity.User{Name: "fake name", Email: "fake@email.com"})
+		require.Nil(t, err)
+
+		counted := make(chan int64)
+		go func() {
+			total, _ := g.Count(context.Background(), igateway.UserFilter{})
+			counted <- total
+		}()
+
+		assert.NoError(t, store.RollbackTx(tx))
+		assert.Equal(t, int64(0), <-counted)
+	})
+
+	t.Run("should refuse changes in a read-only transaction", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+
+		tx, err := store.BeginTx(context.Background(), &sql.TxOptions{ReadOnly: true})
+		require.Nil(t, err)
+		ctx := iinfra.ContextWithTx(context.Background(), tx)
+
+		_, err = g.Count(ctx, igateway.UserFilter{})
+		assert.NoError(t, err)
+		_, err = g.Create(ctx, entity.User{Name: "fake name", Email: "fake@email.com"})
+		assert.Equal(t, errMemoryTxReadOnly, err)
+		assert.NoError(t, store.CommitTx(tx))
+	})
+
+	t.Run("should stop waiting for the current transaction when the context is done", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+
+		tx, err := store.BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		defer store.RollbackTx(tx)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		_, err = store.BeginTx(ctx, nil)
+		assert.Equal(t, context.DeadlineExceeded, err)
+		_, err = g.FindByID(ctx, 1)
+		assert.Equal(t, context.DeadlineExceeded, err)
+	})
+
+	t.Run("should only discard the changes made after the savepoint", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+
+		tx, err := store.BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		ctx := iinfra.ContextWithTx(context.Background(), tx)
+		kept, err := g.Create(ctx, entity.User{Name: "kept", Email: "kept@email.com"})
+		require.Nil(t, err)
+
+		require.Nil(t, store.Savepoint(ctx, tx, "sp"))
+		discarded, err := g.Create(ctx, entity.User{Name: "discarded", Email: "discarded@email.com"})
+		require.Nil(t, err)
+		assert.NoError(t, store.RollbackToSavepoint(ctx, tx, "sp"))
+		assert.NoError(t, store.ReleaseSavepoint(ctx, tx, "sp"))
+		assert.Equal(t, errMemorySavepointNotFound, store.RollbackToSavepoint(ctx, tx, "sp"))
+		assert.NoError(t, store.CommitTx(tx))
+
+		_, err = g.FindByID(context.Background(), kept.ID)
+		assert.NoError(t, err)
+		_, err = g.FindByID(context.Background(), discarded.ID)
+		assert.Equal(t, businesserr.ErrCreateUserNotFound, err)
+		assert.Equal(t, errMemoryTxDone, store.Savepoint(ctx, tx, "sp"))
+	})
+
+	t.Run("should refuse the calls without transaction when the context requires one", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+		ctx := iinfra.WithTxRequired(context.Background())
+
+		_, err := g.Create(ctx, entity.User{Name: "fake name", Email: "fake@email.com"})
+		assert.Equal(t, iinfra.ErrTxRequired, err)
+
+		tx, err := store.BeginTx(ctx, nil)
+		require.Nil(t, err)
+		_, err = g.Create(iinfra.ContextWithTx(ctx, tx), entity.User{Name: "fake name", Email: "fake@email.com"})
+		assert.NoError(t, err)
+		assert.NoError(t, store.CommitTx(tx))
+	})
+
+	t.Run("should refuse the transactions of another store", func(t *testing.T) {
+		store := NewMemoryStore()
+		g := NewMemoryUserGateway(store)
+
+		tx, err := NewMemoryStore().BeginTx(context.Background(), nil)
+		require.Nil(t, err)
+		_, err = g.FindByID(iinfra.ContextWithTx(context.Background(), tx), 1)
+		assert.Equal(t, errMemoryForeignTx, err)
+	})
+
+	t.Run("should assign unique IDs to concurrent creations", func(t *testing.T) {
+		g := NewMemoryUserGateway(NewMemoryStore())
+
+		var wg sync.WaitGroup
+		ids := make(chan int64, 50)
+		for i := 0; i < cap(ids); i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				user, err := g.Create(context.Background(), entity.User{
+					Name:  "fake name",
+					Email: string(rune('a'+i%26)) + string(rune('a'+i/26)) + "@email.com",
+				})
+				assert.NoError(t, err)
+				ids <- user.ID
+			}(i)
+		}
+		wg.Wait()
+		close(ids)
+
+		seen := make(map[int64]bool)
+		for id := range ids {
+			assert.False(t, seen[id], id)
+			seen[id] = true
+		}
+		assert.Len(t, seen, cap(ids))
+	})
+}
@@ -0,0 +1,327 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+)
+
+const memoryUsersTable = "users"
+
+type (
+	memoryUserGateway struct {
+		store *MemoryStore
+	}
+
+	// memoryUsers is the users table, with the same constraints of the SQL one
+	memoryUsers struct {
+		rows   map[int64]memoryUser
+		lastID int64
+	}
+
+	memoryUser struct {
+		entity.User
+		deleted bool
+	}
+)
+
+// NewMemoryUserGateway keeps the users in the store, it has the same semantics of the SQL gateway
+func NewMemoryUserGateway(store *MemoryStore) igateway.User {
+	return memoryUserGateway{
+		store: store,
+	}
+}
+
+func newMemoryUsers() memoryTable {
+	return &memoryUsers{
+		rows: make(map[int64]memoryUser),
+	}
+}
+
+func (m *memoryUsers) clone() memoryTable {
+	rows := make(map[int64]memoryUser, len(m.rows))
+	for id, row := range m.rows {
+		rows[id] = row
+	}
+
+	return &memoryUsers{
+		rows:   rows,
+		lastID: m.lastID,
+	}
+}
+
+// emailInUse checks the unique index on the email of the active users
+func (m *memoryUsers) emailInUse(email string, exceptID int64) bool {
+	for _, row := range m.rows {
+		if !row.deleted && row.Email == email && row.ID != exceptID {
+			return true
+		}
+	}
+	return false
+}
+
+// users runs fn with the users table, that is only changed when write is true
+func (g memoryUserGateway) users(ctx context.Context, write bool, fn func(*memoryUsers) error) error {
+	return g.store.do(ctx, memoryUsersTable, newMemoryUsers, write, func(table memoryTable) error {
+		return fn(table.(*memoryUsers))
+	})
+}
+
+// FindByID ...
+func (g memoryUserGateway) FindByID(ctx context.Context, id int64) (user entity.User, err error) {
+	err = g.users(ctx, false, func(users *memoryUsers) error {
+		row, ok := users.rows[id]
+		if !ok || row.deleted {
+			return businesserr.ErrCreateUserNotFound
+		}
+
+		user = row.User
+		return nil
+	})
+	return
+}
+
+// FindDeletedByID ...
+func (g memoryUserGateway) FindDeletedByID(ctx context.Context, id int64) (user entity.User, err error) {
+	err = g.users(ctx, false, func(users *memoryUsers) error {
+		row, ok := users.rows[id]
+		if !ok || !row.deleted {
+			return businesserr.ErrCreateUserNotFound
+		}
+
+		user = row.User
+		return nil
+	})
+	return
+}
+
+// FindByEmail ...
+func (g memoryUserGateway) FindByEmail(ctx context.Context, email string) (user entity.User, err error) {
+	err = g.users(ctx, false, func(users *memoryUsers) error {
+		for _, row := range users.rows {
+			if !row.deleted && row.Email == email {
+				user = row.User
+				return nil
+			}
+		}
+
+		return businesserr.ErrCreateUserNotFound
+	})
+	return
+}
+
+// FindAll ...
+func (g memoryUserGateway) FindAll(ctx context.Context, filter igateway.UserFilter,
+	page igateway.UserPage) (users []entity.User, err error) {
+	if _, ok := sortColumns[page.SortField]; !ok {
+		err = fmt.Errorf("invalid sort field: %q", page.SortField)
+		return
+	}
+
+	err = g.users(ctx, false, func(table *memoryUsers) error {
+		for _, row := range table.rows {
+			if !row.deleted && memoryFilterMatch(row.User, filter) && memoryAfter(row.User, page) {
+				users = append(users, row.User)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return memoryLess(users[i], users[j], page) != page.SortDesc
+	})
+
+	if page.Limit > 0 && len(users) > page.Limit {
+		users = users[:page.Limit]
+	}
+
+	return
+}
+
+// Count ...
+func (g memoryUserGateway) Count(ctx context.Context, filter igateway.UserFilter) (total int64, err error) {
+	err = g.users(ctx, false, func(users *memoryUsers) error {
+		for _, row := range users.rows {
+			if !row.deleted && memoryFilterMatch(row.User, filter) {
+				total++
+			}
+		}
+		return nil
+	})
+	return
+}
+
+// Create ...
+func (g memoryUserGateway) Create(ctx context.Context, user entity.User) (userCreated entity.User, err error) {
+	err = g.users(ctx, true, func(users *memoryUsers) error {
+		if users.emailInUse(user.Email, 0) {
+			return businesserr.ErrCreateUserAlreadyExists
+		}
+
+		users.lastID++
+		userCreated = entity.User{
+			ID:           users.lastID,
+			Name:         user.Name,
+			Email:        user.Email,
+			DisplayEmail: user.DisplayEmail,
+			CreatedAt:    time.Now().UTC(),
+		}
+		users.rows[userCreated.ID] = memoryUser{User: userCreated}
+		return nil
+	})
+	return
+}
+
+// Update ...
+func (g memoryUserGateway) Update(ctx context.Context, user entity.User) (userUpdated entity.User, err error) {
+	err = g.users(ctx, true, func(users *memoryUsers) error {
+		row, ok := users.rows[user.ID]
+		if !ok || row.deleted {
+			return businesserr.ErrCreateUserNotFound
+		}
+		if users.emailInUse(user.Email, user.ID) {
+			return businesserr.ErrCreateUserAlreadyExists
+		}
+
+		row.Name = user.Name
+		row.Email = user.Email
+		row.DisplayEmail = user.DisplayEmail
+		users.rows[user.ID] = row
+		userUpdated = user
+		return nil
+	})
+	return
+}
+
+// Delete marks the user as deleted, keeping its data to be restored later
+func (g memoryUserGateway) Delete(ctx context.Context, id int64) error {
+	return g.users(ctx, true, func(users *memoryUsers) error {
+		row, ok := users.rows[id]
+		if !ok || row.deleted {
+			return businesserr.ErrCreateUserNotFound
+		}
+
+		row.deleted = true
+		users.rows[id] = row
+		return nil
+	})
+}
+
+// Purge removes the user data permanently, deleted or not
+func (g memoryUserGateway) Purge(ctx context.Context, id int64) error {
+	return g.users(ctx, true, func(users *memoryUsers) error {
+		if _, ok := users.rows[id]; !ok {
+			return businesserr.ErrCreateUserNotFound
+		}
+
+		delete(users.rows, id)
+		return nil
+	})
+}
+
+// Restore ...
+func (g memoryUserGateway) Restore(ctx context.Context, id int64) error {
+	return g.users(ctx, true, func(users *memoryUsers) error {
+		row, ok := users.rows[id]
+		if !ok || !row.deleted {
+			return businesserr.ErrCreateUserNotFound
+		}
+		if users.emailInUse(row.Email, id) {
+			return businesserr.ErrCreateUserAlreadyExists
+		}
+
+		row.deleted = false
+		users.rows[id] = row
+		return nil
+	})
+}
+
+// memoryFilterMatch checks the user against the same conditions of filterQuery
+func memoryFilterMatch(user entity.User, filter igateway.UserFilter) bool {
+	if filter.Email != "" && user.Email != filter.Email {
+		return false
+	}
+
+	if filter.NameContains != "" &&
+		!strings.Contains(foldName(user.Name), foldName(filter.NameContains)) {
+		return false
+	}
+
+	if filter.EmailDomain != "" &&
+		!strings.HasSuffix(strings.ToLower(user.Email), "@"+strings.ToLower(filter.EmailDomain)) {
+		return false
+	}
+
+	if len(filter.IDs) > 0 {
+		found := false
+		for _, id := range filter.IDs {
+			found = found || id == user.ID
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if !filter.CreatedFrom.IsZero() && user.CreatedAt.Before(filter.CreatedFrom) {
+		return false
+	}
+
+	if !filter.CreatedTo.IsZero() && !user.CreatedAt.Before(filter.CreatedTo) {
+		return false
+	}
+
+	return true
+}
+
+// memorySortValue is the value of the field the users are sorted by
+func memorySortValue(user entity.User, field igateway.UserSortField) string {
+	switch field {
+	case igateway.UserSortByName:
+		return user.Name
+	case igateway.UserSortByEmail:
+		return user.Email
+	}
+	return ""
+}
+
+// memoryLess sorts in ascending order by the sort field, ties are broken by the ID
+func memoryLess(a, b entity.User, page igateway.UserPage) bool {
+	va, vb := memorySortValue(a, page.SortField), memorySortValue(b, page.SortField)
+	if va != vb {
+		return va < vb
+	}
+	return a.ID < b.ID
+}
+
+// memoryAfter checks if the user comes after the cursor of the page, as in pageQuery
+func memoryAfter(user entity.User, page igateway.UserPage) bool {
+	if page.After == nil {
+		return true
+	}
+
+	cursor := entity.User{ID: page.After.ID}
+	switch page.SortField {
+	case igateway.UserSortByName:
+		cursor.Name = page.After.Value
+	case igateway.UserSortByEmail:
+		cursor.Email = page.After.Value
+	}
+
+	if page.SortDesc {
+		return memoryLess(user, cursor, page)
+	}
+	return memoryLess(cursor, user, page)
+}
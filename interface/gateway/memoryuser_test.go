@@ -0,0 +1,101 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUserGateway(t *testing.T) {
+	ctx := context.Background()
+	create := func(t *testing.T, g igateway.User, name, email string) entity.User {
+		user, err := g.Create(ctx, entity.User{Name: name, Email: email})
+		require.Nil(t, err)
+		return user
+	}
+
+	t.Run("should return ErrCreateUserNotFound when the user does not exist", func(t *testing.T) {
+		g := NewMemoryUserGateway(NewMemoryStore())
+
+		_, err := g.FindByID(ctx, 1)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+		_, err = g.FindByEmail(ctx, "fake@email.com")
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+		_, err = g.Update(ctx, entity.User{ID: 1})
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+		assert.EqualError(t, g.Delete(ctx, 1), businesserr.ErrCreateUserNotFound.Error())
+		assert.EqualError(t, g.Purge(ctx, 1), businesserr.ErrCreateUserNotFound.Error())
+		assert.EqualError(t, g.Restore(ctx, 1), businesserr.ErrCreateUserNotFound.Error())
+	})
+
+	t.Run("should keep the emails of the active users unique", func(t *testing.T) {
+		g := NewMemoryUserGateway(NewMemoryStore())
+		first := create(t, g, "first", "fake@email.com")
+
+		_, err := g.Create(ctx, entity.User{Name: "second", Email: "fake@email.com"})
+		assert.EqualError(t, err, businesserr.ErrCreateUserAlreadyExists.Error())
+
+		// the email is free again after the deletion, until the first user is restored
+		require.Nil(t, g.Delete(ctx, first.ID))
+		second := create(t, g, "second", "fake@email.com")
+		assert.EqualError(t, g.Restore(ctx, first.ID), businesserr.ErrCreateUserAlreadyExists.Error())
+
+		other := create(t, g, "other", "other@email.com")
+		_, err = g.Update(ctx, entity.User{ID: other.ID, Name: "other", Email: second.Email})
+		assert.EqualError(t, err, businesserr.ErrCreateUserAlreadyExists.Error())
+	})
+
+	t.Run("should find the deleted users only by FindDeletedByID", func(t *testing.T) {
+		g := NewMemoryUserGateway(NewMemoryStore())
+		user := create(t, g, "fake name", "fake@email.com")
+		require.Nil(t, g.Delete(ctx, user.ID))
+
+		_, err := g.FindByID(ctx, user.ID)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+		deleted, err := g.FindDeletedByID(ctx, user.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, user, deleted)
+
+		require.Nil(t, g.Purge(ctx, user.ID))
+		_, err = g.FindDeletedByID(ctx, user.ID)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+	})
+
+	t.Run("should filter, sort and paginate the users", func(t *testing.T) {
+		g := NewMemoryUserGateway(NewMemoryStore())
+		bob := create(t, g, "Bob", "bob@one.com")
+		alice := create(t, g, "Alice", "alice@two.com")
+		carol := create(t, g, "Carol", "carol@ONE.com")
+		create(t, g, "Dave", "dave@two.com")
+
+		users, err := g.FindAll(ctx, igateway.UserFilter{EmailDomain: "one.com"},
+			igateway.UserPage{SortField: igateway.UserSortByName, SortDesc: true})
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.User{carol, bob}, users)
+
+		users, err = g.FindAll(ctx, igateway.UserFilter{}, igateway.UserPage{
+			Limit:     2,
+			After:     &igateway.UserCursor{ID: bob.ID, Value: bob.Name},
+			SortField: igateway.UserSortByName,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.User{carol}, users[:1])
+		assert.Len(t, users, 2)
+
+		total, err := g.Count(ctx, igateway.UserFilter{NameContains: "a", IDs: []int64{alice.ID, carol.ID, bob.ID}})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), total)
+
+		_, err = g.FindAll(ctx, igateway.UserFilter{}, igateway.UserPage{SortField: "password"})
+		assert.Error(t, err)
+	})
+}
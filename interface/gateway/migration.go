@@ -0,0 +1,634 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+//go:embed migrations
+var migrationsFS embed.FS
+
+// schemaMigrationsExists counts the schema_migrations tables of the current schema, by dialect. It's how the
+// status is read without creating the table
+var schemaMigrationsExists = map[iinfra.Dialect]string{
+	iinfra.DialectSQLite3: "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'",
+	iinfra.DialectPostgres: "SELECT COUNT(*) FROM information_schema.tables " +
+		"WHERE table_schema = current_schema() AND table_name = 'schema_migrations'",
+	iinfra.DialectMySQL: "SELECT COUNT(*) FROM information_schema.tables " +
+		"WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'",
+}
+
+// migrationLockKey is the key of the advisory lock of the migrators, a fixed number for the
+// pg_advisory_xact_lock of postgres and a name for the GET_LOCK of mysql
+const (
+	migrationLockKey  = 7204391157
+	migrationLockName = "schema_migrations"
+)
+
+// migrationLocks are the statements taking and releasing the lock that keeps two migrators from applying the
+// same migration, by dialect. Postgres releases it at the end of the transaction, and sqlite3 needs none: its
+// transactions take the write lock when they begin, see NewSQLite3
+var migrationLocks = map[iinfra.Dialect]struct{ lock, unlock string }{
+	iinfra.DialectSQLite3:  {},
+	iinfra.DialectPostgres: {lock: fmt.Sprintf("SELECT 1 FROM pg_advisory_xact_lock(%d)", migrationLockKey)},
+	iinfra.DialectMySQL: {
+		lock:   fmt.Sprintf("SELECT GET_LOCK('%s', -1)", migrationLockName),
+		unlock: fmt.Sprintf("SELECT RELEASE_LOCK('%s')", migrationLockName),
+	},
+}
+
+// migrationFileName matches the migration scripts, ex: 0001_create_users.up.sql
+var migrationFileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type (
+	// Migrator keeps the database schema in the version expected by the gateways. Each migration is applied
+	// with its registration in a transaction holding a lock, so concurrent migrators apply it once. MySQL
+	// commits the DDL statements implicitly though, so there a failed migration can be left partially
+	// applied and unregistered, and must be fixed by hand
+	Migrator interface {
+		// Up applies every pending migration, in order
+		Up(ctx context.Context) error
+		// Down reverts the last applied migrations
+		Down(ctx context.Context, steps int) error
+		// Status lists every known migration and when it was applied. It only reads the database, so it can
+		// be called by the readiness probes and with a read-only user
+		Status(ctx context.Context) ([]MigrationStatus, error)
+	}
+
+	// MigrationStatus ...
+	MigrationStatus struct {
+		Version   int64
+		Name      string
+		AppliedAt *time.Time // nil when the migration is pending
+	}
+
+	migration struct {
+		version  int64
+		name     string
+		up       string
+		down     string
+		checksum string
+	}
+
+	appliedMigration struct {
+		version   int64
+		checksum  string
+		appliedAt time.Time
+	}
+
+	// migrationStep fills the data of a migration that SQL can't compute the same way in every dialect. It
+	// runs in the transaction of the migration, after its up script
+	migrationStep func(ctx context.Context, db iinfra.Database) error
+
+	migrator struct {
+		db     iinfra.Database
+		logger iinfra.LogProvider
+		fsys   fs.FS
+		dir    string
+		steps  map[int64]migrationStep // by version
+	}
+)
+
+// NewMigrator canonicalizes the stored emails with the rules, the ones of the interactors
+func NewMigrator(db iinfra.Database, logger iinfra.LogProvider, emailRules entity.EmailRules) Migrator {
+	m := newMigrator(db, logger, migrationsFS, path.Join("migrations", string(db.Dialect())))
+	m.steps = map[int64]migrationStep{
+		3: canonicalizeEmails(emailRules),
+		4: fillNameFolded,
+	}
+	return m
+}
+
+func newMigrator(db iinfra.Database, logger iinfra.LogProvider, fsys fs.FS, dir string) migrator {
+	return migrator{
+		db:     db,
+		logger: logger,
+		fsys:   fsys,
+		dir:    dir,
+	}
+}
+
+// Up ...
+func (m migrator) Up(ctx context.Context) (err error) {
+	startTime := time.Now()
+	m.logger.Debug(ctx, "starting migrate up method")
+
+	migrations, applied, err := m.load(ctx, false)
+	if err != nil {
+		return
+	}
+
+	for _, mig := range migrations {
+		if _, ok := applied[mig.version]; ok {
+			continue
+		}
+
+		err = m.apply(ctx, mig, mig.up, true, func(ctx context.Context) error {
+			if step, ok := m.steps[mig.version]; ok {
+				if err := step(ctx, m.db); err != nil {
+					return err
+				}
+			}
+			_, err := m.db.Exec(ctx, rebind(m.db.Dialect(),
+				"INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)"),
+				mig.version, mig.name, mig.checksum, time.Now().UTC())
+			return err
+		})
+		if err != nil {
+			m.logger.Error(ctx, fmt.Sprintf("error when applying migration: %v", err), iinfra.LogAttrs{
+				"version": mig.version,
+				"name":    mig.name,
+			})
+			return
+		}
+
+		m.logger.Info(ctx, "migration applied", iinfra.LogAttrs{
+			"version": mig.version,
+			"name":    mig.name,
+		})
+	}
+
+	m.logger.Debug(ctx, "ending migrate up method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Down ...
+func (m migrator) Down(ctx context.Context, steps int) (err error) {
+	startTime := time.Now()
+	m.logger.Debug(ctx, "starting migrate down method")
+
+	migrations, applied, err := m.load(ctx, false)
+	if err != nil {
+		return
+	}
+
+	// revert from the newest to the oldest
+	for i := len(migrations) - 1; i >= 0 && steps > 0; i-- {
+		mig := migrations[i]
+		if _, ok := applied[mig.version]; !ok {
+			continue
+		}
+
+		err = m.apply(ctx, mig, mig.down, false, func(ctx context.Context) error {
+			_, err := m.db.Exec(ctx, rebind(m.db.Dialect(), "DELETE FROM schema_migrations WHERE version = ?"),
+				mig.version)
+			return err
+		})
+		if err != nil {
+			m.logger.Error(ctx, fmt.Sprintf("error when reverting migration: %v", err), iinfra.LogAttrs{
+				"version": mig.version,
+				"name":    mig.name,
+			})
+			return
+		}
+
+		m.logger.Info(ctx, "migration reverted", iinfra.LogAttrs{
+			"version": mig.version,
+			"name":    mig.name,
+		})
+		steps--
+	}
+
+	m.logger.Debug(ctx, "ending migrate down method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Status ...
+func (m migrator) Status(ctx context.Context) (status []MigrationStatus, err error) {
+	migrations, applied, err := m.load(ctx, true)
+	if err != nil {
+		return
+	}
+
+	for _, mig := range migrations {
+		s := MigrationStatus{
+			Version: mig.version,
+			Name:    mig.name,
+		}
+		if a, ok := applied[mig.version]; ok {
+			appliedAt := a.appliedAt
+			s.AppliedAt = &appliedAt
+		}
+
+		status = append(status, s)
+	}
+
+	return
+}
+
+// load reads the known migrations and the applied ones, checking if they still match. The schema_migrations
+// table is created if needed, unless readOnly, when its absence means that no migration was applied
+func (m migrator) load(ctx context.Context, readOnly bool) (migrations []migration,
+	applied map[int64]appliedMigration, err error) {
+	migrations, err = loadMigrations(m.fsys, m.dir)
+	if err != nil {
+		m.logger.Error(ctx, fmt.Sprintf("error when loading migrations: %v", err))
+		return
+	}
+
+	if readOnly {
+		var exists bool
+		if exists, err = m.tableExists(ctx); err != nil || !exists {
+			return migrations, map[int64]appliedMigration{}, err
+		}
+	} else {
+		_, err = m.db.Exec(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations ("+
+			"version BIGINT PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum CHAR(64) NOT NULL, "+
+			"applied_at TIMESTAMP NOT NULL)")
+		if err != nil {
+			m.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err))
+			return
+		}
+	}
+
+	applied, err = m.applied(ctx)
+	if err != nil {
+		return
+	}
+
+	known := make(map[int64]migration, len(migrations))
+	for _, mig := range migrations {
+		known[mig.version] = mig
+	}
+
+	// a migration can't be changed or removed after applied, the schema would be unknown
+	for version, a := range applied {
+		mig, ok := known[version]
+		if !ok {
+			err = fmt.Errorf("applied migration %d is unknown", version)
+		} else if mig.checksum != a.checksum {
+			err = fmt.Errorf("applied migration %d_%s was changed", version, mig.name)
+		}
+		if err != nil {
+			m.logger.Error(ctx, err.Error())
+			return
+		}
+	}
+
+	return
+}
+
+// tableExists checks if the schema_migrations table was created
+func (m migrator) tableExists(ctx context.Context) (exists bool, err error) {
+	query, ok := schemaMigrationsExists[m.db.Dialect()]
+	if !ok {
+		err = fmt.Errorf("unknown dialect: %s", m.db.Dialect())
+		return
+	}
+
+	var rows *sql.Rows
+	rows, err = m.db.Query(ctx, query)
+	if err != nil {
+		m.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err))
+		return
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			m.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err))
+			return
+		}
+	}
+
+	return count > 0, rows.Err()
+}
+
+// applied reads the migrations already registered at the schema_migrations table
+func (m migrator) applied(ctx context.Context) (applied map[int64]appliedMigration, err error) {
+	var rows *sql.Rows
+	rows, err = m.db.Query(ctx, "SELECT version, checksum, applied_at FROM schema_migrations")
+	if err != nil {
+		m.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err))
+		return
+	}
+	defer rows.Close()
+
+	applied = make(map[int64]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err = rows.Scan(&a.version, &a.checksum, &a.appliedAt); err != nil {
+			m.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err))
+			return
+		}
+
+		applied[a.version] = a
+	}
+
+	return
+}
+
+// apply executes the script of the migration and registers it in the same transaction, holding the migration
+// lock. Nothing is done when, once the lock is taken, the migration is already applied, or already reverted
+// when !up, by another migrator
+func (m migrator) apply(ctx context.Context, mig migration, script string, up bool,
+	register func(context.Context) error) (err error) {
+	locks, ok := migrationLocks[m.db.Dialect()]
+	if !ok {
+		return fmt.Errorf("unknown dialect: %s", m.db.Dialect())
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return
+	}
+	ctx = iinfra.ContextWithTx(ctx, tx)
+
+	if err = m.lock(ctx, locks.lock); err != nil {
+		_ = m.db.RollbackTx(tx)
+		return
+	}
+
+	var registered bool
+	if registered, err = m.registered(ctx, mig.version); err == nil && registered != up {
+		for _, statement := range splitStatements(script) {
+			if _, err = m.db.Exec(ctx, statement); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = register(ctx)
+		}
+	}
+
+	// the mysql lock belongs to the connection, it's released before the connection goes back to the pool
+	if locks.unlock != "" {
+		if _, unlockErr := m.db.Exec(ctx, locks.unlock); err == nil {
+			err = unlockErr
+		}
+	}
+	if err != nil {
+		_ = m.db.RollbackTx(tx)
+		return
+	}
+	if registered == up {
+		m.logger.Debug(ctx, "migration already done by another migrator", iinfra.LogAttrs{
+			"version": mig.version,
+			"name":    mig.name,
+		})
+		return m.db.RollbackTx(tx)
+	}
+
+	return m.db.CommitTx(tx)
+}
+
+// lock takes the migration lock with the statement of the dialect, waiting for it as long as ctx allows
+func (m migrator) lock(ctx context.Context, statement string) (err error) {
+	if statement == "" {
+		return
+	}
+
+	var rows *sql.Rows
+	rows, err = m.db.Query(ctx, statement)
+	if err != nil {
+		m.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err))
+		return
+	}
+	defer rows.Close()
+
+	// the statements return 1 once the lock is taken, GET_LOCK returns NULL on errors
+	var taken sql.NullInt64
+	if rows.Next() {
+		if err = rows.Scan(&taken); err != nil {
+			m.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err))
+			return
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return
+	}
+	if taken.Int64 != 1 {
+		err = errors.New("the migration lock couldn't be taken")
+	}
+
+	return
+}
+
+// registered checks if the migration is at the schema_migrations table
+func (m migrator) registered(ctx context.Context, version int64) (registered bool, err error) {
+	var rows *sql.Rows
+	rows, err = m.db.Query(ctx, rebind(m.db.Dialect(), "SELECT COUNT(*) FROM schema_migrations WHERE version = ?"),
+		version)
+	if err != nil {
+		m.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err))
+		return
+	}
+	defer rows.Close()
+
+	var count int
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			m.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err))
+			return
+		}
+	}
+
+	return count > 0, rows.Err()
+}
+
+// canonicalizeEmails stores the canonical form of the existing addresses, the one the interactors look for.
+// The migration is refused when two active users would have the same address, one of them must be changed
+// first. The invalid addresses are kept, no valid one can match them
+func canonicalizeEmails(rules entity.EmailRules) migrationStep {
+	return func(ctx context.Context, db iinfra.Database) error {
+		rows, err := db.Query(ctx,
+			"SELECT id, email, display_email, CASE WHEN deleted_at IS NULL THEN 1 ELSE 0 END FROM users ORDER BY id")
+		if err != nil {
+			return err
+		}
+
+		// the rows are read before the updates, which run in the same connection
+		canonical := make(map[int64]string)
+		activeByEmail := make(map[string]int64)
+		for rows.Next() {
+			var id int64
+			var email, displayEmail string
+			var active int
+			if err = rows.Scan(&id, &email, &displayEmail, &active); err != nil {
+				rows.Close()
+				return err
+			}
+
+			c, ok := entity.CanonicalEmail(displayEmail, rules)
+			if !ok {
+				continue
+			}
+			if other, found := activeByEmail[c]; found && active == 1 {
+				rows.Close()
+				return fmt.Errorf("users %d and %d have the same canonical email %s, one of them must be changed "+
+					"before migrating", other, id, c)
+			}
+			if active == 1 {
+				activeByEmail[c] = id
+			}
+			if c != email {
+				canonical[id] = c
+			}
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		for id, email := range canonical {
+			_, err = db.Exec(ctx, rebind(db.Dialect(), "UPDATE users SET email = ? WHERE id = ?"), email, id)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// fillNameFolded fills the name_folded column of the existing users, see foldName
+func fillNameFolded(ctx context.Context, db iinfra.Database) error {
+	rows, err := db.Query(ctx, "SELECT id, name FROM users")
+	if err != nil {
+		return err
+	}
+
+	// the rows are read before the updates, which run in the same connection
+	names := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return err
+		}
+		names[id] = name
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for id, name := range names {
+		_, err = db.Exec(ctx, rebind(db.Dialect(), "UPDATE users SET name_folded = ? WHERE id = ?"), foldName(name), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitStatements splits the script at the semicolons that end its statements, leaving out the comments.
+// They are executed one by one, so the drivers don't need to accept many statements in one call, which
+// would let an injected query stack statements, ex: the multiStatements of mysql
+func splitStatements(script string) (statements []string) {
+	var b strings.Builder
+	flush := func() {
+		if statement := strings.TrimSpace(b.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		b.Reset()
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '-' && strings.HasPrefix(script[i:], "--"):
+			// the comment goes until the end of the line
+			end := strings.IndexByte(script[i:], '\n') + i
+			if end < i {
+				end = len(script)
+			}
+			i = end - 1
+		case c == '\'' || c == '"' || c == '`':
+			// the quotes are escaped by doubling them, so the doubled ones open a new quoted part
+			end := strings.IndexByte(script[i+1:], c) + i + 1
+			if end <= i {
+				end = len(script) - 1 // unterminated, the database tells the error
+			}
+			b.WriteString(script[i : end+1])
+			i = end
+		case c == ';':
+			flush()
+		default:
+			b.WriteByte(c)
+		}
+	}
+	flush()
+
+	return
+}
+
+// loadMigrations reads the up and down scripts of dir, sorted by version
+func loadMigrations(fsys fs.FS, dir string) (migrations []migration, err error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return
+	}
+
+	byVersion := make(map[int64]*migration)
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &migration{version: version, name: match[2]}
+			byVersion[version] = mig
+		} else if mig.name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, mig.name, match[2])
+		}
+
+		var content []byte
+		if content, err = fs.ReadFile(fsys, path.Join(dir, entry.Name())); err != nil {
+			return
+		}
+
+		if match[3] == "up" {
+			sum := sha256.Sum256(content)
+			mig.up = string(content)
+			mig.checksum = hex.EncodeToString(sum[:])
+		} else {
+			mig.down = string(content)
+		}
+	}
+
+	for _, mig := range byVersion {
+		if mig.up == "" || mig.down == "" {
+			return nil, fmt.Errorf("migration %d_%s must have both up and down scripts", mig.version, mig.name)
+		}
+
+		migrations = append(migrations, *mig)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return
+}
@@ -0,0 +1,485 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/infra"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/interface/iinfra/mock_iinfra"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrator(t *testing.T) {
+	createTable := regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS schema_migrations")
+	selectApplied := regexp.QuoteMeta("SELECT version, checksum, applied_at FROM schema_migrations")
+	insertApplied := regexp.QuoteMeta("INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)")
+	deleteApplied := regexp.QuoteMeta("DELETE FROM schema_migrations WHERE version = ?")
+	selectRegistered := regexp.QuoteMeta("SELECT COUNT(*) FROM schema_migrations WHERE version = ?")
+	tableExists := regexp.QuoteMeta("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'")
+	fakeAppliedAt := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"migrations/0001_create_a.up.sql":   {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		"migrations/0001_create_a.down.sql": {Data: []byte("DROP TABLE a")},
+		"migrations/0002_create_b.up.sql":   {Data: []byte("CREATE TABLE b (id INTEGER)")},
+		"migrations/0002_create_b.down.sql": {Data: []byte("DROP TABLE b")},
+		"migrations/README.md":              {Data: []byte("ignored")},
+	}
+	checksum := func(script string) string {
+		sum := sha256.Sum256([]byte(script))
+		return hex.EncodeToString(sum[:])
+	}
+
+	t.Run("should apply only the pending migrations, in order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, checksum("CREATE TABLE a (id INTEGER)"), fakeAppliedAt))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectRegistered).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INTEGER)")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertApplied).WithArgs(2, "create_b", checksum("CREATE TABLE b (id INTEGER)"), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), logger, fsys, "migrations")
+		assert.NoError(t, m.Up(context.Background()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should rollback and stop when a migration fails", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		fakeError := errors.New("fake error")
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectRegistered).WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE a (id INTEGER)")).WillReturnError(fakeError)
+		mock.ExpectRollback()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), logger, fsys, "migrations")
+		assert.EqualError(t, m.Up(context.Background()), fakeError.Error())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should skip the migrations applied by another migrator while waiting for the lock", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, checksum("CREATE TABLE a (id INTEGER)"), fakeAppliedAt))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectRegistered).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectRollback()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), "migration already done by another migrator", gomock.Any())
+		logger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), logger, fsys, "migrations")
+		assert.NoError(t, m.Up(context.Background()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should hold the mysql lock while applying a migration", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, checksum("CREATE TABLE a (id INTEGER)"), fakeAppliedAt))
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT GET_LOCK('schema_migrations', -1)")).
+			WillReturnRows(sqlmock.NewRows([]string{"lock"}).AddRow(1))
+		mock.ExpectQuery(selectRegistered).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE b (id INTEGER)")).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertApplied).WithArgs(2, "create_b", checksum("CREATE TABLE b (id INTEGER)"), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("SELECT RELEASE_LOCK('schema_migrations')")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDialectDatabase(ctrl, db, iinfra.DialectMySQL), logger, fsys, "migrations")
+		assert.NoError(t, m.Up(context.Background()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error when an applied migration was changed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, checksum("CREATE TABLE a (id TEXT)"), fakeAppliedAt))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), logger, fsys, "migrations")
+		assert.EqualError(t, m.Up(context.Background()), "applied migration 1_create_a was changed")
+	})
+
+	t.Run("should return an error when an applied migration is unknown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(3, checksum("CREATE TABLE c (id INTEGER)"), fakeAppliedAt))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), logger, fsys, "migrations")
+		assert.EqualError(t, m.Up(context.Background()), "applied migration 3 is unknown")
+	})
+
+	t.Run("should revert the last applied migrations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(createTable).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, checksum("CREATE TABLE a (id INTEGER)"), fakeAppliedAt).
+			AddRow(2, checksum("CREATE TABLE b (id INTEGER)"), fakeAppliedAt))
+		mock.ExpectBegin()
+		mock.ExpectQuery(selectRegistered).WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectExec("DROP TABLE b").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(deleteApplied).WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Info(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), logger, fsys, "migrations")
+		assert.NoError(t, m.Down(context.Background(), 1))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should list every migration with its status", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		// without creating the schema_migrations table
+		mock.ExpectQuery(tableExists).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(selectApplied).WillReturnRows(sqlmock.NewRows([]string{"version", "checksum", "applied_at"}).
+			AddRow(1, checksum("CREATE TABLE a (id INTEGER)"), fakeAppliedAt))
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), mock_iinfra.NewMockLogProvider(ctrl), fsys, "migrations")
+		status, err := m.Status(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []MigrationStatus{
+			{Version: 1, Name: "create_a", AppliedAt: &fakeAppliedAt},
+			{Version: 2, Name: "create_b"},
+		}, status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should list every migration as pending when the schema_migrations table doesn't exist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(tableExists).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+		m := newMigrator(sqlmockDatabase(ctrl, db), mock_iinfra.NewMockLogProvider(ctrl), fsys, "migrations")
+		status, err := m.Status(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, []MigrationStatus{
+			{Version: 1, Name: "create_a"},
+			{Version: 2, Name: "create_b"},
+		}, status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("should return an error when a migration has no down script", func(t *testing.T) {
+		_, err := loadMigrations(fstest.MapFS{
+			"migrations/0001_create_a.up.sql": {Data: []byte("CREATE TABLE a (id INTEGER)")},
+		}, "migrations")
+
+		assert.EqualError(t, err, "migration 1_create_a must have both up and down scripts")
+	})
+
+	t.Run("should split the scripts into their statements", func(t *testing.T) {
+		statements := splitStatements("-- a comment; with a semicolon\n" +
+			"CREATE TABLE a (id INTEGER, name TEXT DEFAULT 'a;''b');\n\n" +
+			"UPDATE a SET name = \"x;y\" -- trailing; comment\n;\n;" +
+			"DROP TABLE `a;b`")
+
+		assert.Equal(t, []string{
+			"CREATE TABLE a (id INTEGER, name TEXT DEFAULT 'a;''b')",
+			"UPDATE a SET name = \"x;y\"",
+			"DROP TABLE `a;b`",
+		}, statements)
+	})
+
+	t.Run("should embed valid migrations", func(t *testing.T) {
+		for _, dialect := range []iinfra.Dialect{iinfra.DialectSQLite3, iinfra.DialectPostgres, iinfra.DialectMySQL} {
+			migrations, err := loadMigrations(migrationsFS, path.Join("migrations", string(dialect)))
+
+			assert.NoError(t, err, dialect)
+			assert.NotEmpty(t, migrations, dialect)
+			for _, mig := range migrations {
+				assert.NotEmpty(t, splitStatements(mig.up), mig.name)
+				assert.NotEmpty(t, splitStatements(mig.down), mig.name)
+			}
+		}
+	})
+}
+
+func TestMigrationSteps(t *testing.T) {
+	ctx := context.Background()
+	logger, err := infra.NewLogrus("panic", infra.LogFormatJSON)
+	require.NoError(t, err)
+
+	// migrate migrates the database up to the version, the users are created by the caller in between
+	migrate := func(t *testing.T, db iinfra.Database, version int64, rules entity.EmailRules) error {
+		m := NewMigrator(db, logger, rules).(migrator)
+		m.fsys = migrationsUpTo(t, m.dir, version)
+		return m.Up(ctx)
+	}
+
+	// insertUser inserts a user as the migrations before the 3rd stored it
+	insertUser := func(t *testing.T, db iinfra.Database, name, email string, deleted bool) {
+		query := "INSERT INTO users (name, email) VALUES (?, ?)"
+		if deleted {
+			query = "INSERT INTO users (name, email, deleted_at) VALUES (?, ?, CURRENT_TIMESTAMP)"
+		}
+		_, err := db.Exec(ctx, query, name, email)
+		require.NoError(t, err)
+	}
+
+	// emails are the email and the display_email of the users, by name
+	emails := func(t *testing.T, db iinfra.Database) map[string][2]string {
+		rows, err := db.Query(ctx, "SELECT name, email, display_email FROM users")
+		require.NoError(t, err)
+		defer rows.Close()
+
+		emails := make(map[string][2]string)
+		for rows.Next() {
+			var name, email, displayEmail string
+			require.NoError(t, rows.Scan(&name, &email, &displayEmail))
+			emails[name] = [2]string{email, displayEmail}
+		}
+		require.NoError(t, rows.Err())
+		return emails
+	}
+
+	t.Run("should canonicalize the emails of the existing users", func(t *testing.T) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, migrate(t, db, 2, entity.EmailRules{}))
+		insertUser(t, db, "foo", " Foo@Bücher.Example ", false)
+		insertUser(t, db, "bar", "bar@email.com", false)
+		insertUser(t, db, "invalid", " not an email ", false)
+		require.NoError(t, migrate(t, db, 3, entity.EmailRules{}))
+
+		assert.Equal(t, map[string][2]string{
+			"foo":     {"foo@xn--bcher-kva.example", "Foo@Bücher.Example"},
+			"bar":     {"bar@email.com", "bar@email.com"},
+			"invalid": {" not an email ", "not an email"},
+		}, emails(t, db))
+	})
+
+	t.Run("should keep the case of the local part if it's case sensitive", func(t *testing.T) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, migrate(t, db, 2, entity.EmailRules{}))
+		insertUser(t, db, "foo", "Foo@Email.COM", false)
+		require.NoError(t, migrate(t, db, 3, entity.EmailRules{CaseSensitiveLocalPart: true}))
+
+		assert.Equal(t, "Foo@email.com", emails(t, db)["foo"][0])
+	})
+
+	t.Run("should refuse to migrate if two active users would have the same email", func(t *testing.T) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, migrate(t, db, 2, entity.EmailRules{}))
+		insertUser(t, db, "foo", "foo@email.com", false)
+		insertUser(t, db, "FOO", "FOO@email.com ", false)
+		err = migrate(t, db, 3, entity.EmailRules{})
+
+		assert.EqualError(t, err, "users 1 and 2 have the same canonical email foo@email.com, one of them must "+
+			"be changed before migrating")
+		rows, err := db.Query(ctx, "SELECT email FROM users WHERE name = ?", "FOO")
+		require.NoError(t, err)
+		defer rows.Close()
+		require.True(t, rows.Next())
+		var email string
+		require.NoError(t, rows.Scan(&email))
+		assert.Equal(t, "FOO@email.com ", email)
+	})
+
+	t.Run("should not refuse to migrate if the users with the same email are deleted", func(t *testing.T) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, migrate(t, db, 2, entity.EmailRules{}))
+		insertUser(t, db, "foo", "foo@email.com", true)
+		insertUser(t, db, "FOO", "FOO@email.com", false)
+
+		assert.NoError(t, migrate(t, db, 3, entity.EmailRules{}))
+	})
+
+	t.Run("should fill the name_folded of the existing users", func(t *testing.T) {
+		db, err := infra.NewSQLite3(filepath.Join(t.TempDir(), "users.db"))
+		require.NoError(t, err)
+		defer db.Close()
+
+		require.NoError(t, migrate(t, db, 3, entity.EmailRules{}))
+		_, err = db.Exec(ctx, "INSERT INTO users (name, email, display_email) VALUES (?, ?, ?)",
+			"ÉMILE Zola", "emile@email.com", "emile@email.com")
+		require.NoError(t, err)
+		require.NoError(t, migrate(t, db, 4, entity.EmailRules{}))
+
+		rows, err := db.Query(ctx, "SELECT name_folded FROM users")
+		require.NoError(t, err)
+		defer rows.Close()
+		require.True(t, rows.Next())
+		var folded string
+		require.NoError(t, rows.Scan(&folded))
+		assert.Equal(t, "émile zola", folded)
+	})
+}
+
+// migrationsUpTo are the embedded migrations of dir until the version
+func migrationsUpTo(t *testing.T, dir string, version int64) fstest.MapFS {
+	entries, err := fs.ReadDir(migrationsFS, dir)
+	require.NoError(t, err)
+
+	fsys := fstest.MapFS{}
+	for _, entry := range entries {
+		m := migrationFileName.FindStringSubmatch(entry.Name())
+		if v, _ := strconv.ParseInt(m[1], 10, 64); v > version {
+			continue
+		}
+		data, err := fs.ReadFile(migrationsFS, path.Join(dir, entry.Name()))
+		require.NoError(t, err)
+		fsys[path.Join(dir, entry.Name())] = &fstest.MapFile{Data: data}
+	}
+	return fsys
+}
+
+// sqlmockDatabase forwards the database calls to the sqlmock connection
+func sqlmockDatabase(ctrl *gomock.Controller, db *sql.DB) iinfra.Database {
+	return sqlmockDialectDatabase(ctrl, db, iinfra.DialectSQLite3)
+}
+
+// sqlmockDialectDatabase is sqlmockDatabase with the dialect of the statements
+func sqlmockDialectDatabase(ctrl *gomock.Controller, db *sql.DB, dialect iinfra.Dialect) iinfra.Database {
+	database := mock_iinfra.NewMockDatabase(ctrl)
+	database.EXPECT().Dialect().Return(dialect).AnyTimes()
+	database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+			if tx, ok := iinfra.TxFromContext(ctx); ok {
+				return tx.(*sql.Tx).Query(query, args...)
+			}
+			return db.Query(query, args...)
+		})
+	database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+			if tx, ok := iinfra.TxFromContext(ctx); ok {
+				return tx.(*sql.Tx).Exec(query, args...)
+			}
+			return db.Exec(query, args...)
+		})
+	database.EXPECT().BeginTx(gomock.Any(), gomock.Any()).AnyTimes().DoAndReturn(
+		func(ctx context.Context, opts *sql.TxOptions) (iinfra.Tx, error) {
+			return db.BeginTx(ctx, opts)
+		})
+	database.EXPECT().CommitTx(gomock.Any()).AnyTimes().DoAndReturn(func(tx iinfra.Tx) error {
+		return tx.(*sql.Tx).Commit()
+	})
+	database.EXPECT().RollbackTx(gomock.Any()).AnyTimes().DoAndReturn(func(tx iinfra.Tx) error {
+		return tx.(*sql.Tx).Rollback()
+	})
+	return database
+}
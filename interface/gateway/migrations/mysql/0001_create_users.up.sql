@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGINT       NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    created_at DATETIME(6)  NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    deleted_at DATETIME(6)  NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,5 @@
+-- deleted users keep their email, so only the active ones must be unique. There are no partial
+-- indexes in MySQL, but NULL values never collide in an unique index
+ALTER TABLE users
+    ADD COLUMN active_email VARCHAR(255) AS (IF(deleted_at IS NULL, email, NULL)) STORED,
+    ADD UNIQUE INDEX users_email_unique (active_email);
@@ -0,0 +1,5 @@
+-- email keeps the canonical form of the address, the one that must be unique, and display_email
+-- the form informed by the user. The existing addresses are canonicalized by the migrator, as
+-- entity.CanonicalEmail does
+ALTER TABLE users ADD COLUMN display_email VARCHAR(255) NOT NULL DEFAULT '';
+UPDATE users SET display_email = TRIM(email);
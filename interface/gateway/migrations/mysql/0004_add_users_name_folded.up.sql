@@ -0,0 +1,4 @@
+-- name_folded keeps the case folded name, filled by the gateway, so the name filter matches the same
+-- users in every dialect. Its collation is binary, the default one would also ignore the accents. The
+-- existing rows are filled by the migrator
+ALTER TABLE users ADD COLUMN name_folded VARCHAR(1024) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- deleted users keep their email, so only the active ones must be unique
+CREATE UNIQUE INDEX users_email_unique ON users (email) WHERE deleted_at IS NULL;
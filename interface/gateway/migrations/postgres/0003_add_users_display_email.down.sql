@@ -0,0 +1,2 @@
+UPDATE users SET email = display_email;
+ALTER TABLE users DROP COLUMN IF EXISTS display_email;
@@ -0,0 +1,15 @@
+-- this version of SQLite can't drop columns, so the table is rebuilt without it
+UPDATE users SET email = display_email;
+DROP INDEX IF EXISTS users_email_unique;
+CREATE TABLE users_without_display_email (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    name       TEXT      NOT NULL,
+    email      TEXT      NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL
+);
+INSERT INTO users_without_display_email (id, name, email, created_at, deleted_at)
+SELECT id, name, email, created_at, deleted_at FROM users;
+DROP TABLE users;
+ALTER TABLE users_without_display_email RENAME TO users;
+CREATE UNIQUE INDEX users_email_unique ON users (email) WHERE deleted_at IS NULL;
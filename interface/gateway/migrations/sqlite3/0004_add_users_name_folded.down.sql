@@ -0,0 +1,15 @@
+-- this version of SQLite can't drop columns, so the table is rebuilt without it
+DROP INDEX IF EXISTS users_email_unique;
+CREATE TABLE users_without_name_folded (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    name          TEXT      NOT NULL,
+    email         TEXT      NOT NULL,
+    created_at    TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at    TIMESTAMP NULL,
+    display_email TEXT      NOT NULL DEFAULT ''
+);
+INSERT INTO users_without_name_folded (id, name, email, created_at, deleted_at, display_email)
+SELECT id, name, email, created_at, deleted_at, display_email FROM users;
+DROP TABLE users;
+ALTER TABLE users_without_name_folded RENAME TO users;
+CREATE UNIQUE INDEX users_email_unique ON users (email) WHERE deleted_at IS NULL;
@@ -0,0 +1,3 @@
+-- name_folded keeps the case folded name, filled by the gateway, so the name filter matches the same
+-- users in every dialect: LOWER only folds ASCII in SQLite. The existing rows are filled by the migrator
+ALTER TABLE users ADD COLUMN name_folded TEXT NOT NULL DEFAULT '';
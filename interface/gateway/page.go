@@ -0,0 +1,54 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+)
+
+// sortColumns maps the sort fields to the columns, it also avoids SQL injection through the sort field
+var sortColumns = map[igateway.UserSortField]string{
+	"":                       "id",
+	igateway.UserSortByID:    "id",
+	igateway.UserSortByName:  "name",
+	igateway.UserSortByEmail: "email",
+}
+
+// pageQuery appends the keyset pagination to the query. Instead of an OFFSET, that makes the database walk
+// through all previous pages, it filters by the last (sort column, id) pair seen, so deep pages stay fast.
+// The query must already have a WHERE clause, see filterQuery.
+func pageQuery(query, column string, page igateway.UserPage) (string, []interface{}) {
+	var args []interface{}
+
+	direction, comparison := "ASC", ">"
+	if page.SortDesc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if page.After != nil {
+		if column == "id" {
+			query += fmt.Sprintf(" AND id %s ?", comparison)
+			args = append(args, page.After.ID)
+		} else {
+			query += fmt.Sprintf(" AND (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", column, comparison)
+			args = append(args, page.After.Value, page.After.Value, page.After.ID)
+		}
+	}
+
+	if column == "id" {
+		query += fmt.Sprintf(" ORDER BY id %s", direction)
+	} else {
+		query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s", column, direction)
+	}
+
+	if page.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, page.Limit)
+	}
+
+	return query, args
+}
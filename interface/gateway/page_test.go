@@ -0,0 +1,49 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package gateway
+
+import (
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageQuery(t *testing.T) {
+	const query = "SELECT id FROM users WHERE deleted_at IS NULL"
+
+	t.Run("should only sort by id when the page is empty", func(t *testing.T) {
+		q, args := pageQuery(query, "id", igateway.UserPage{})
+		assert.Equal(t, query+" ORDER BY id ASC", q)
+		assert.Empty(t, args)
+	})
+
+	t.Run("should filter by the last id seen when sorting by id", func(t *testing.T) {
+		q, args := pageQuery(query, "id", igateway.UserPage{
+			Limit: 10,
+			After: &igateway.UserCursor{ID: 5},
+		})
+		assert.Equal(t, query+" AND id > ? ORDER BY id ASC LIMIT ?", q)
+		assert.Equal(t, []interface{}{int64(5), 10}, args)
+	})
+
+	t.Run("should invert the comparison when sorting in descending order", func(t *testing.T) {
+		q, args := pageQuery(query, "id", igateway.UserPage{
+			After:    &igateway.UserCursor{ID: 5},
+			SortDesc: true,
+		})
+		assert.Equal(t, query+" AND id < ? ORDER BY id DESC", q)
+		assert.Equal(t, []interface{}{int64(5)}, args)
+	})
+
+	t.Run("should break ties by id when sorting by another column", func(t *testing.T) {
+		q, args := pageQuery(query, "email", igateway.UserPage{
+			Limit: 10,
+			After: &igateway.UserCursor{ID: 5, Value: "fake@email.com"},
+		})
+		assert.Equal(t, query+" AND (email > ? OR (email = ? AND id > ?)) ORDER BY email ASC, id ASC LIMIT ?", q)
+		assert.Equal(t, []interface{}{"fake@email.com", "fake@email.com", int64(5), 10}, args)
+	})
+}
@@ -7,6 +7,7 @@ package gateway
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -22,40 +23,94 @@ import (
 // default error when query execution fails
 const errorExecutingQuery = "error when executing query: %v"
 
+// userColumns are the columns scanned by scanUser, in order
+const userColumns = "id, name, email, display_email, created_at"
+
+// metricQueryDuration is the latency of the methods of the gateway, by method and outcome
+const metricQueryDuration = "gateway_query_duration_seconds"
+
 type userGateway struct {
-	db     iinfra.Database
-	logger iinfra.LogProvider
+	db      iinfra.Database
+	logger  iinfra.LogProvider
+	metrics iinfra.MetricsProvider
+	tracer  iinfra.Tracer
 }
 
-// NewUserGateway ...
-func NewUserGateway(db iinfra.Database, logger iinfra.LogProvider) igateway.User {
+// NewUserGateway traces each method and each of its statements
+func NewUserGateway(db iinfra.Database, logger iinfra.LogProvider, metrics iinfra.MetricsProvider,
+	tracer iinfra.Tracer) igateway.User {
 	return userGateway{
-		db:     db,
-		logger: logger,
+		db:      db,
+		logger:  logger,
+		metrics: metrics,
+		tracer:  tracer,
+	}
+}
+
+// FindByID ...
+func (u userGateway) FindByID(ctx context.Context, id int64) (user entity.User, err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.find_by_id", nil)
+	defer u.observe(span, "find_by_id", startTime, &err)
+	u.logger.Debug(ctx, "starting find by id method")
+
+	var found bool
+	user, found, err = u.findOne(ctx, iinfra.LogAttrs{"id": id},
+		"SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NULL", id)
+	if err != nil {
+		return
+	}
+	if !found {
+		// will return an error if the user does not exists
+		err = businesserr.ErrCreateUserNotFound
+	}
+
+	u.logger.Debug(ctx, "ending find by id method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// FindDeletedByID ...
+func (u userGateway) FindDeletedByID(ctx context.Context, id int64) (user entity.User, err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.find_deleted_by_id", nil)
+	defer u.observe(span, "find_deleted_by_id", startTime, &err)
+	u.logger.Debug(ctx, "starting find deleted by id method")
+
+	var found bool
+	user, found, err = u.findOne(ctx, iinfra.LogAttrs{"id": id},
+		"SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		return
+	}
+	if !found {
+		// will return an error if the user does not exists
+		err = businesserr.ErrCreateUserNotFound
 	}
+
+	u.logger.Debug(ctx, "ending find deleted by id method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
 }
 
 // FindByEmail ...
 func (u userGateway) FindByEmail(ctx context.Context, email string) (user entity.User, err error) {
 	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.find_by_email", nil)
+	defer u.observe(span, "find_by_email", startTime, &err)
 	u.logger.Debug(ctx, "starting find by email method")
 
-	var rows *sql.Rows
-	rows, err = u.db.Query(ctx, "SELECT id, name, email FROM users WHERE email = ?", email)
+	var found bool
+	user, found, err = u.findOne(ctx, iinfra.LogAttrs{"email": email},
+		"SELECT "+userColumns+" FROM users WHERE email = ? AND deleted_at IS NULL", email)
 	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), iinfra.LogAttrs{"email": email})
 		return
 	}
-
-	if rows.Next() {
-		// get just the first line
-		err = rows.Scan(&user.ID, &user.Name, &user.Email)
-		if err != nil {
-			u.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err),
-				iinfra.LogAttrs{"email": email})
-			return
-		}
-	} else {
+	if !found {
 		// will return an error if the user does not exists
 		err = businesserr.ErrCreateUserNotFound
 	}
@@ -70,47 +125,144 @@ func (u userGateway) FindByEmail(ctx context.Context, email string) (user entity
 // Create ...
 func (u userGateway) Create(ctx context.Context, user entity.User) (userCreated entity.User, err error) {
 	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.create", nil)
+	defer u.observe(span, "create", startTime, &err)
 	u.logger.Debug(ctx, "starting create user method")
 
-	result, err := u.db.Exec(ctx, "INSERT INTO users (name, email) VALUES (?, ?)", user.Name, user.Email)
+	createdAt := time.Now().UTC()
+	id, err := u.insert(ctx, iinfra.LogAttrs{"user": user},
+		"INSERT INTO users (name, name_folded, email, display_email, created_at) VALUES (?, ?, ?, ?, ?)",
+		user.Name, foldName(user.Name), user.Email, user.DisplayEmail, createdAt)
 	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), iinfra.LogAttrs{"user": user})
+		// another user got the email after the interactor has checked it
+		err = constraintErr(err)
 		return
 	}
 
-	// get the ID of the user that was created
-	id, err := result.LastInsertId()
+	u.logger.Debug(ctx, "ending create user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return entity.User{
+		ID:           id,
+		Name:         user.Name,
+		Email:        user.Email,
+		DisplayEmail: user.DisplayEmail,
+		CreatedAt:    createdAt,
+	}, err
+}
+
+// Update ...
+func (u userGateway) Update(ctx context.Context, user entity.User) (userUpdated entity.User, err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.update", nil)
+	defer u.observe(span, "update", startTime, &err)
+	u.logger.Debug(ctx, "starting update user method")
+
+	err = u.execOne(ctx, iinfra.LogAttrs{"user": user},
+		"UPDATE users SET name = ?, name_folded = ?, email = ?, display_email = ? WHERE id = ? AND deleted_at IS NULL",
+		user.Name, foldName(user.Name), user.Email, user.DisplayEmail, user.ID)
 	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf("error when getting last insert ID: %v", err), iinfra.LogAttrs{"user": user})
+		err = constraintErr(err)
 		return
 	}
 
-	u.logger.Debug(ctx, "ending create user method", iinfra.LogAttrs{
+	u.logger.Debug(ctx, "ending update user method", iinfra.LogAttrs{
 		"duration": time.Since(startTime),
 	})
 
-	return entity.User{
-		ID:    id,
-		Name:  user.Name,
-		Email: user.Email,
-	}, err
+	return user, err
+}
+
+// Delete marks the user as deleted, keeping its data to be restored later
+func (u userGateway) Delete(ctx context.Context, id int64) (err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.delete", nil)
+	defer u.observe(span, "delete", startTime, &err)
+	u.logger.Debug(ctx, "starting delete user method")
+
+	err = u.execOne(ctx, iinfra.LogAttrs{"id": id},
+		"UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL", id)
+	if err != nil {
+		return
+	}
+
+	u.logger.Debug(ctx, "ending delete user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Purge removes the user data permanently, deleted or not
+func (u userGateway) Purge(ctx context.Context, id int64) (err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.purge", nil)
+	defer u.observe(span, "purge", startTime, &err)
+	u.logger.Debug(ctx, "starting purge user method")
+
+	err = u.execOne(ctx, iinfra.LogAttrs{"id": id}, "DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return
+	}
+
+	u.logger.Debug(ctx, "ending purge user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Restore ...
+func (u userGateway) Restore(ctx context.Context, id int64) (err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.restore", nil)
+	defer u.observe(span, "restore", startTime, &err)
+	u.logger.Debug(ctx, "starting restore user method")
+
+	err = u.execOne(ctx, iinfra.LogAttrs{"id": id},
+		"UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL", id)
+	if err != nil {
+		err = constraintErr(err)
+		return
+	}
+
+	u.logger.Debug(ctx, "ending restore user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
 }
 
 // FindAll ...
-func (u userGateway) FindAll(ctx context.Context) (users []entity.User, err error) {
+func (u userGateway) FindAll(ctx context.Context, filter igateway.UserFilter,
+	page igateway.UserPage) (users []entity.User, err error) {
 	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.find_all", nil)
+	defer u.observe(span, "find_all", startTime, &err)
 	u.logger.Debug(ctx, "starting find all users method")
 
+	column, ok := sortColumns[page.SortField]
+	if !ok {
+		err = fmt.Errorf("invalid sort field: %q", page.SortField)
+		u.logger.Error(ctx, err.Error())
+		return
+	}
+
+	query, args := filterQuery("SELECT "+userColumns+" FROM users", filter)
+	query, pageArgs := pageQuery(query, column, page)
+
 	var rows *sql.Rows
-	rows, err = u.db.Query(ctx, "SELECT id, name, email FROM users")
+	rows, err = u.query(ctx, query, append(args, pageArgs...)...)
 	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err))
+		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), iinfra.LogAttrs{"filter": filter, "page": page})
 		return
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var user entity.User
-		err = rows.Scan(&user.ID, &user.Name, &user.Email)
+		user, err = scanUser(rows)
 		if err != nil {
 			u.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err))
 			return
@@ -125,3 +277,184 @@ func (u userGateway) FindAll(ctx context.Context) (users []entity.User, err erro
 
 	return
 }
+
+// Count ...
+func (u userGateway) Count(ctx context.Context, filter igateway.UserFilter) (total int64, err error) {
+	startTime := time.Now()
+	ctx, span := u.tracer.Start(ctx, "gateway.user.count", nil)
+	defer u.observe(span, "count", startTime, &err)
+	u.logger.Debug(ctx, "starting count users method")
+
+	query, args := filterQuery("SELECT COUNT(*) FROM users", filter)
+
+	var rows *sql.Rows
+	rows, err = u.query(ctx, query, args...)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), iinfra.LogAttrs{"filter": filter})
+		return
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		if err = rows.Scan(&total); err != nil {
+			u.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err))
+			return
+		}
+	}
+
+	u.logger.Debug(ctx, "ending count users method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// insert executes the statement and returns the ID of the row inserted
+func (u userGateway) insert(ctx context.Context, attrs iinfra.LogAttrs, query string,
+	args ...interface{}) (id int64, err error) {
+	// postgres drivers don't support LastInsertId, the ID must be returned by the statement itself
+	if u.db.Dialect() == iinfra.DialectPostgres {
+		var rows *sql.Rows
+		rows, err = u.query(ctx, query+" RETURNING id", args...)
+		if err != nil {
+			u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), attrs)
+			return
+		}
+		defer rows.Close()
+
+		if !rows.Next() {
+			err = rows.Err()
+			if err == nil {
+				err = sql.ErrNoRows
+			}
+			u.logger.Error(ctx, fmt.Sprintf("error when getting the inserted ID: %v", err), attrs)
+			return
+		}
+		if err = rows.Scan(&id); err != nil {
+			u.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err), attrs)
+		}
+		return
+	}
+
+	result, err := u.exec(ctx, query, args...)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), attrs)
+		return
+	}
+
+	// get the ID of the row that was inserted
+	id, err = result.LastInsertId()
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when getting last insert ID: %v", err), attrs)
+	}
+	return
+}
+
+// query executes the query using the bind vars of the database dialect. Its span ends once the query
+// is executed, the scan of the rows isn't part of it
+func (u userGateway) query(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	query = rebind(u.db.Dialect(), query)
+	ctx, span := u.traceStatement(ctx, "db.query", query)
+	defer endStatement(span, &err)
+	return u.db.Query(ctx, query, args...)
+}
+
+// exec executes the statement using the bind vars of the database dialect
+func (u userGateway) exec(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	query = rebind(u.db.Dialect(), query)
+	ctx, span := u.traceStatement(ctx, "db.exec", query)
+	defer endStatement(span, &err)
+	return u.db.Exec(ctx, query, args...)
+}
+
+// traceStatement starts the span of the SQL statement, the args aren't traced since they have personal data
+func (u userGateway) traceStatement(ctx context.Context, name, query string) (context.Context, iinfra.Span) {
+	return u.tracer.Start(ctx, name, iinfra.SpanAttrs{
+		"db.system":    string(u.db.Dialect()),
+		"db.statement": query,
+	})
+}
+
+// findOne executes the query and scans just the first line, if any
+func (u userGateway) findOne(ctx context.Context, attrs iinfra.LogAttrs, query string,
+	args ...interface{}) (user entity.User, found bool, err error) {
+	var rows *sql.Rows
+	rows, err = u.query(ctx, query, args...)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), attrs)
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return
+	}
+
+	user, err = scanUser(rows)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when scanning query result: %v", err), attrs)
+		return
+	}
+
+	return user, true, nil
+}
+
+// observe records the latency of the method and ends its span, the business errors, ex: a user not
+// found, are not errors of the query
+func (u userGateway) observe(span iinfra.Span, method string, startTime time.Time, err *error) {
+	outcome := "ok"
+	var be businesserr.BusinessError
+	if *err != nil && !errors.As(*err, &be) {
+		outcome = "error"
+		span.RecordError(*err)
+	}
+	span.End()
+
+	u.metrics.Histogram(metricQueryDuration, iinfra.MetricLabels{
+		"method":  method,
+		"outcome": outcome,
+	}, time.Since(startTime).Seconds())
+}
+
+// endStatement ends the span of the statement, with its error if any
+func endStatement(span iinfra.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+	}
+	span.End()
+}
+
+// scanUser scans the userColumns of the current row
+func scanUser(rows *sql.Rows) (user entity.User, err error) {
+	err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.DisplayEmail, &user.CreatedAt)
+	return
+}
+
+// constraintErr turns the constraint violations into the errors of the gateway contract, the unique
+// index of the email is the only one that a valid statement can violate
+func constraintErr(err error) error {
+	if errors.Is(err, iinfra.ErrUniqueViolation) {
+		return businesserr.ErrCreateUserAlreadyExists
+	}
+	return err
+}
+
+// execOne executes the statement, will return ErrCreateUserNotFound if no row was affected
+func (u userGateway) execOne(ctx context.Context, attrs iinfra.LogAttrs, query string, args ...interface{}) error {
+	result, err := u.exec(ctx, query, args...)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf(errorExecutingQuery, err), attrs)
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when getting rows affected: %v", err), attrs)
+		return err
+	}
+	if affected == 0 {
+		return businesserr.ErrCreateUserNotFound
+	}
+
+	return nil
+}
@@ -8,22 +8,30 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
 	"github.com/dougefr/go-clean-arch/interface/iinfra/mock_iinfra"
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// fakeCreatedAt is the creation time of the users returned by the queries
+var fakeCreatedAt = time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
 func TestUserGatewayFindByEmail(t *testing.T) {
-	const query = "SELECT id, name, email FROM users WHERE email = ?"
+	query := regexp.QuoteMeta("SELECT id, name, email, display_email, created_at FROM users WHERE email = ? AND deleted_at IS NULL")
 	const fakeName = "fake name"
 	const fakeEmail = "fake@email.com"
+	const fakeDisplayEmail = "Fake@Email.com"
 	fakeError := errors.New("fake error")
 
 	t.Run("should return an error if the query results in an error", func(t *testing.T) {
@@ -41,12 +49,13 @@ func TestUserGatewayFindByEmail(t *testing.T) {
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 				return db.Query(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		_, err = g.FindByEmail(context.Background(), fakeEmail)
 		assert.EqualError(t, err, fakeError.Error())
 	})
@@ -59,19 +68,20 @@ func TestUserGatewayFindByEmail(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "name", "email"})
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
 		mock.ExpectQuery(query).WithArgs(fakeEmail).WillReturnRows(rows)
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 				return db.Query(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		_, err = g.FindByEmail(context.Background(), fakeEmail)
 		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
 	})
@@ -84,8 +94,8 @@ func TestUserGatewayFindByEmail(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "name", "email"})
-		rows.AddRow("invalid id type", fakeName, fakeEmail)
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow("invalid id type", fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
 		mock.ExpectQuery(query).WithArgs(fakeEmail).WillReturnRows(rows)
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
@@ -93,12 +103,13 @@ func TestUserGatewayFindByEmail(t *testing.T) {
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 				return db.Query(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		_, err = g.FindByEmail(context.Background(), fakeEmail)
 		assert.EqualError(t, err, "sql: Scan error on column index 0, name \"id\": converting driver.Value type string (\"invalid id type\") to a int64: invalid syntax")
 	})
@@ -111,33 +122,281 @@ func TestUserGatewayFindByEmail(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "name", "email"})
-		rows.AddRow(1, fakeName, fakeEmail)
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow(1, fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
 		mock.ExpectQuery(query).WithArgs(fakeEmail).WillReturnRows(rows)
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 				return db.Query(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		user, _ := g.FindByEmail(context.Background(), fakeEmail)
 		assert.Equal(t, entity.User{
-			ID:    1,
-			Name:  fakeName,
-			Email: fakeEmail,
+			ID:           1,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+			CreatedAt:    fakeCreatedAt,
+		}, user)
+	})
+}
+
+func TestUserGatewayFindByID(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT id, name, email, display_email, created_at FROM users WHERE id = ? AND deleted_at IS NULL")
+	const fakeID = int64(1)
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	const fakeDisplayEmail = "Fake@Email.com"
+	fakeError := errors.New("fake error")
+
+	t.Run("should return an error if the query results in an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnError(fakeError)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindByID(context.Background(), fakeID)
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return ErrCreateUserNotFound when query return no results", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindByID(context.Background(), fakeID)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+	})
+
+	t.Run("should return an error if occur an error when scanning the result query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow("invalid id type", fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindByID(context.Background(), fakeID)
+		assert.EqualError(t, err, "sql: Scan error on column index 0, name \"id\": converting driver.Value type string (\"invalid id type\") to a int64: invalid syntax")
+	})
+
+	t.Run("should return the user found if the query results any user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow(fakeID, fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		user, _ := g.FindByID(context.Background(), fakeID)
+		assert.Equal(t, entity.User{
+			ID:           fakeID,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+			CreatedAt:    fakeCreatedAt,
+		}, user)
+	})
+}
+
+func TestUserGatewayFindDeletedByID(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT id, name, email, display_email, created_at FROM users WHERE id = ? AND deleted_at IS NOT NULL")
+	const fakeID = int64(1)
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	const fakeDisplayEmail = "Fake@Email.com"
+	fakeError := errors.New("fake error")
+
+	t.Run("should return an error if the query results in an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnError(fakeError)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindDeletedByID(context.Background(), fakeID)
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return ErrCreateUserNotFound when query return no results", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindDeletedByID(context.Background(), fakeID)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+	})
+
+	t.Run("should return an error if occur an error when scanning the result query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow("invalid id type", fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindDeletedByID(context.Background(), fakeID)
+		assert.EqualError(t, err, "sql: Scan error on column index 0, name \"id\": converting driver.Value type string (\"invalid id type\") to a int64: invalid syntax")
+	})
+
+	t.Run("should return the user found if the query results any user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow(fakeID, fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(query).WithArgs(fakeID).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		user, _ := g.FindDeletedByID(context.Background(), fakeID)
+		assert.Equal(t, entity.User{
+			ID:           fakeID,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+			CreatedAt:    fakeCreatedAt,
 		}, user)
 	})
 }
 
 func TestUserGatewayCreate(t *testing.T) {
-	query := regexp.QuoteMeta("INSERT INTO users (name, email) VALUES (?, ?)")
+	query := regexp.QuoteMeta("INSERT INTO users (name, name_folded, email, display_email, created_at) VALUES (?, ?, ?, ?, ?)")
 	const fakeName = "fake name"
 	const fakeEmail = "fake@email.com"
+	const fakeDisplayEmail = "Fake@Email.com"
 	fakeError := errors.New("fake error")
 
 	t.Run("should return an error if the query results in an error", func(t *testing.T) {
@@ -148,22 +407,24 @@ func TestUserGatewayCreate(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(query).WithArgs(fakeName, fakeEmail).WillReturnError(fakeError)
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, sqlmock.AnyArg()).WillReturnError(fakeError)
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
 				return db.Exec(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		_, err = g.Create(context.Background(), entity.User{
-			Name:  fakeName,
-			Email: fakeEmail,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
 		})
 		assert.EqualError(t, err, fakeError.Error())
 	})
@@ -176,22 +437,24 @@ func TestUserGatewayCreate(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(query).WithArgs(fakeName, fakeEmail).WillReturnResult(sqlmock.NewErrorResult(fakeError))
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewErrorResult(fakeError))
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
 				return db.Exec(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		_, err = g.Create(context.Background(), entity.User{
-			Name:  fakeName,
-			Email: fakeEmail,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
 		})
 		assert.EqualError(t, err, fakeError.Error())
 	})
@@ -204,37 +467,35 @@ func TestUserGatewayCreate(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		mock.ExpectExec(query).WithArgs(fakeName, fakeEmail).WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(1, 1))
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
 		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
 				return db.Exec(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		user, _ := g.Create(context.Background(), entity.User{
-			Name:  fakeName,
-			Email: fakeEmail,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
 		})
+		assert.False(t, user.CreatedAt.IsZero())
 		assert.Equal(t, entity.User{
-			ID:    1,
-			Name:  fakeName,
-			Email: fakeEmail,
+			ID:           1,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+			CreatedAt:    user.CreatedAt,
 		}, user)
 	})
-}
 
-func TestUserGatewayFindAll(t *testing.T) {
-	const query = "SELECT id, name, email FROM users"
-	const fakeName = "fake name"
-	const fakeEmail = "fake@email.com"
-	fakeError := errors.New("fake error")
-
-	t.Run("should return an error if the query results in an error", func(t *testing.T) {
+	t.Run("should return ErrCreateUserAlreadyExists if the email is already used", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -242,24 +503,30 @@ func TestUserGatewayFindAll(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		mock.ExpectQuery(query).WillReturnError(fakeError)
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, sqlmock.AnyArg()).
+			WillReturnError(fmt.Errorf("%w: duplicate entry", iinfra.ErrUniqueViolation))
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
-		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-				return db.Query(query, args...)
+		database.EXPECT().Dialect().Return(iinfra.DialectMySQL).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
-		_, err = g.FindAll(context.Background())
-		assert.EqualError(t, err, fakeError.Error())
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Create(context.Background(), entity.User{
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+		})
+		assert.EqualError(t, err, businesserr.ErrCreateUserAlreadyExists.Error())
 	})
 
-	t.Run("should return an empty slice when query return no results", func(t *testing.T) {
+	t.Run("should return the ID returned by the insert on postgres", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -267,24 +534,31 @@ func TestUserGatewayFindAll(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "name", "email"})
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		rows := sqlmock.NewRows([]string{"id"}).AddRow(7)
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (name, name_folded, email, display_email, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id")).
+			WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, sqlmock.AnyArg()).WillReturnRows(rows)
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectPostgres).AnyTimes()
 		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 				return db.Query(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
-		result, _ := g.FindAll(context.Background())
-		assert.Empty(t, result)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		user, err := g.Create(context.Background(), entity.User{
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(7), user.ID)
 	})
 
-	t.Run("should return an error if occur an error when scanning the result query", func(t *testing.T) {
+	t.Run("should return an error if the insert returns no ID on postgres", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -292,26 +566,44 @@ func TestUserGatewayFindAll(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "name", "email"})
-		rows.AddRow("invalid id type", fakeName, fakeEmail)
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectQuery(regexp.QuoteMeta("RETURNING id")).WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectPostgres).AnyTimes()
 		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
 			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
 				return db.Query(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
-		_, err = g.FindAll(context.Background())
-		assert.EqualError(t, err, "sql: Scan error on column index 0, name \"id\": converting driver.Value type string (\"invalid id type\") to a int64: invalid syntax")
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Create(context.Background(), entity.User{
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeDisplayEmail,
+		})
+		assert.EqualError(t, err, sql.ErrNoRows.Error())
 	})
+}
 
-	t.Run("should return all users found if the query results any user", func(t *testing.T) {
+func TestUserGatewayUpdate(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE users SET name = ?, name_folded = ?, email = ?, display_email = ? WHERE id = ? AND deleted_at IS NULL")
+	const fakeID = int64(1)
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	const fakeDisplayEmail = "Fake@Email.com"
+	fakeError := errors.New("fake error")
+	fakeUser := entity.User{
+		ID:           fakeID,
+		Name:         fakeName,
+		Email:        fakeEmail,
+		DisplayEmail: fakeDisplayEmail,
+	}
+
+	t.Run("should return an error if the query results in an error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -319,33 +611,579 @@ func TestUserGatewayFindAll(t *testing.T) {
 		require.Nil(t, err)
 		defer db.Close()
 
-		rows := sqlmock.NewRows([]string{"id", "name", "email"})
-		rows.AddRow(1, fakeName, fakeEmail)
-		rows.AddRow(2, fakeName, fakeEmail)
-		mock.ExpectQuery(query).WillReturnRows(rows)
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, fakeID).WillReturnError(fakeError)
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
-		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
 
 		database := mock_iinfra.NewMockDatabase(ctrl)
-		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
-				return db.Query(query, args...)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
 			})
 
-		g := NewUserGateway(database, logger)
-		users, _ := g.FindAll(context.Background())
-		assert.Equal(t, []entity.User{
-			{
-				ID:    1,
-				Name:  fakeName,
-				Email: fakeEmail,
-			},
-			{
-				ID:    2,
-				Name:  fakeName,
-				Email: fakeEmail,
-			},
-		}, users)
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Update(context.Background(), fakeUser)
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return ErrCreateUserAlreadyExists if another user has the email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, fakeID).
+			WillReturnError(fmt.Errorf("%w: duplicate entry", iinfra.ErrUniqueViolation))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Update(context.Background(), fakeUser)
+		assert.EqualError(t, err, businesserr.ErrCreateUserAlreadyExists.Error())
 	})
+
+	t.Run("should return an error if occur an error when getting rows affected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, fakeID).WillReturnResult(sqlmock.NewErrorResult(fakeError))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Update(context.Background(), fakeUser)
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return ErrCreateUserNotFound when no row was affected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, fakeID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Update(context.Background(), fakeUser)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+	})
+
+	t.Run("should return the user updated if everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeName, fakeName, fakeEmail, fakeDisplayEmail, fakeID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		user, _ := g.Update(context.Background(), fakeUser)
+		assert.Equal(t, fakeUser, user)
+	})
+}
+
+func TestUserGatewayDelete(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE users SET deleted_at = CURRENT_TIMESTAMP WHERE id = ? AND deleted_at IS NULL")
+	testUserGatewayExecByID(t, query, func(g igateway.User, id int64) error {
+		return g.Delete(context.Background(), id)
+	})
+}
+
+func TestUserGatewayPurge(t *testing.T) {
+	query := regexp.QuoteMeta("DELETE FROM users WHERE id = ?")
+	testUserGatewayExecByID(t, query, func(g igateway.User, id int64) error {
+		return g.Purge(context.Background(), id)
+	})
+}
+
+func TestUserGatewayRestore(t *testing.T) {
+	query := regexp.QuoteMeta("UPDATE users SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL")
+	testUserGatewayExecByID(t, query, func(g igateway.User, id int64) error {
+		return g.Restore(context.Background(), id)
+	})
+}
+
+// testUserGatewayExecByID runs the common cases of the methods that change just one user by its ID
+func testUserGatewayExecByID(t *testing.T, query string, exec func(g igateway.User, id int64) error) {
+	const fakeID = int64(1)
+	fakeError := errors.New("fake error")
+
+	t.Run("should return an error if the query results in an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeID).WillReturnError(fakeError)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		err = exec(NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl)), fakeID)
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return ErrCreateUserNotFound when no row was affected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		err = exec(NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl)), fakeID)
+		assert.EqualError(t, err, businesserr.ErrCreateUserNotFound.Error())
+	})
+
+	t.Run("should return no error if the user was changed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectExec(query).WithArgs(fakeID).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Exec(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (sql.Result, error) {
+				return db.Exec(query, args...)
+			})
+
+		err = exec(NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl)), fakeID)
+		assert.NoError(t, err)
+	})
+}
+
+func TestUserGatewayFindAll(t *testing.T) {
+	const query = "SELECT id, name, email, display_email, created_at FROM users WHERE deleted_at IS NULL ORDER BY id ASC"
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	const fakeDisplayEmail = "Fake@Email.com"
+	fakeError := errors.New("fake error")
+
+	t.Run("should return an error if the query results in an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WillReturnError(fakeError)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{})
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return an error if the sort field is invalid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+
+		g := NewUserGateway(nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err := g.FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{SortField: "password"})
+		assert.EqualError(t, err, `invalid sort field: "password"`)
+	})
+
+	t.Run("should pass the page to the query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow(3, fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, email, display_email, created_at FROM users WHERE deleted_at IS NULL "+
+			"AND (name < ? OR (name = ? AND id < ?)) ORDER BY name DESC, id DESC LIMIT ?")).
+			WithArgs(fakeName, fakeName, 2, 10).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		users, err := g.FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{
+			Limit:     10,
+			After:     &igateway.UserCursor{ID: 2, Value: fakeName},
+			SortField: igateway.UserSortByName,
+			SortDesc:  true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []entity.User{{ID: 3, Name: fakeName, Email: fakeEmail, DisplayEmail: fakeDisplayEmail, CreatedAt: fakeCreatedAt}}, users)
+	})
+
+	t.Run("should return an empty slice when query return no results", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		result, _ := g.FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{})
+		assert.Empty(t, result)
+	})
+
+	t.Run("should return an error if occur an error when scanning the result query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow("invalid id type", fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{})
+		assert.EqualError(t, err, "sql: Scan error on column index 0, name \"id\": converting driver.Value type string (\"invalid id type\") to a int64: invalid syntax")
+	})
+
+	t.Run("should return all users found if the query results any user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"id", "name", "email", "display_email", "created_at"})
+		rows.AddRow(1, fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		rows.AddRow(2, fakeName, fakeEmail, fakeDisplayEmail, fakeCreatedAt)
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		users, _ := g.FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{})
+		assert.Equal(t, []entity.User{
+			{
+				ID:           1,
+				Name:         fakeName,
+				Email:        fakeEmail,
+				DisplayEmail: fakeDisplayEmail,
+				CreatedAt:    fakeCreatedAt,
+			},
+			{
+				ID:           2,
+				Name:         fakeName,
+				Email:        fakeEmail,
+				DisplayEmail: fakeDisplayEmail,
+				CreatedAt:    fakeCreatedAt,
+			},
+		}, users)
+	})
+}
+
+func TestUserGatewayCount(t *testing.T) {
+	query := regexp.QuoteMeta("SELECT COUNT(*) FROM users WHERE deleted_at IS NULL")
+	fakeError := errors.New("fake error")
+
+	t.Run("should return an error if the query results in an error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		mock.ExpectQuery(query).WillReturnError(fakeError)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Count(context.Background(), igateway.UserFilter{})
+		assert.EqualError(t, err, fakeError.Error())
+	})
+
+	t.Run("should return an error if occur an error when scanning the result query", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"count"})
+		rows.AddRow("invalid count type")
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		_, err = g.Count(context.Background(), igateway.UserFilter{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should return the number of users", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		db, mock, err := sqlmock.New()
+		require.Nil(t, err)
+		defer db.Close()
+
+		rows := sqlmock.NewRows([]string{"count"})
+		rows.AddRow(42)
+		mock.ExpectQuery(query).WillReturnRows(rows)
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectSQLite3).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+				return db.Query(query, args...)
+			})
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		total, _ := g.Count(context.Background(), igateway.UserFilter{})
+		assert.Equal(t, int64(42), total)
+	})
+}
+
+func TestUserGatewayObserve(t *testing.T) {
+	t.Run("should record the latency by method and outcome, the business errors are ok", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		metrics := mock_iinfra.NewMockMetricsProvider(ctrl)
+		metrics.EXPECT().Histogram(metricQueryDuration, iinfra.MetricLabels{"method": "count", "outcome": "ok"},
+			gomock.Any()).Times(2)
+		metrics.EXPECT().Histogram(metricQueryDuration, iinfra.MetricLabels{"method": "count", "outcome": "error"},
+			gomock.Any())
+
+		fakeErr := errors.New("fake-error")
+		span := mock_iinfra.NewMockSpan(ctrl)
+		span.EXPECT().RecordError(fakeErr)
+		span.EXPECT().End().Times(3)
+
+		g := userGateway{metrics: metrics}
+		for _, err := range []error{nil, businesserr.ErrCreateUserNotFound, fakeErr} {
+			g.observe(span, "count", time.Now(), &err)
+		}
+	})
+}
+
+func TestUserGatewayTrace(t *testing.T) {
+	t.Run("should trace the method and its statement", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		database := mock_iinfra.NewMockDatabase(ctrl)
+		database.EXPECT().Dialect().Return(iinfra.DialectPostgres).AnyTimes()
+		database.EXPECT().Query(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("fake-error"))
+		logger.EXPECT().Error(gomock.Any(), gomock.Any(), gomock.Any())
+
+		methodSpan := mock_iinfra.NewMockSpan(ctrl)
+		methodSpan.EXPECT().RecordError(gomock.Any())
+		methodSpan.EXPECT().End()
+		statementSpan := mock_iinfra.NewMockSpan(ctrl)
+		statementSpan.EXPECT().RecordError(gomock.Any())
+		statementSpan.EXPECT().End()
+
+		tracer := mock_iinfra.NewMockTracer(ctrl)
+		gomock.InOrder(
+			tracer.EXPECT().Start(gomock.Any(), "gateway.user.find_by_id", nil).DoAndReturn(
+				func(ctx context.Context, _ string, _ iinfra.SpanAttrs) (context.Context, iinfra.Span) {
+					return ctx, methodSpan
+				}),
+			tracer.EXPECT().Start(gomock.Any(), "db.query", iinfra.SpanAttrs{
+				"db.system":    "postgres",
+				"db.statement": "SELECT " + userColumns + " FROM users WHERE id = $1 AND deleted_at IS NULL",
+			}).Return(context.Background(), statementSpan),
+		)
+
+		g := NewUserGateway(database, logger, anyMetrics(ctrl), tracer)
+		_, err := g.FindByID(context.Background(), 1)
+		assert.Error(t, err)
+	})
+}
+
+// anyTracer starts a span that accepts every call each time
+func anyTracer(ctrl *gomock.Controller) iinfra.Tracer {
+	span := mock_iinfra.NewMockSpan(ctrl)
+	span.EXPECT().SetAttrs(gomock.Any()).AnyTimes()
+	span.EXPECT().RecordError(gomock.Any()).AnyTimes()
+	span.EXPECT().End().AnyTimes()
+	span.EXPECT().TraceID().Return("fake-trace-id").AnyTimes()
+
+	tracer := mock_iinfra.NewMockTracer(ctrl)
+	tracer.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ iinfra.SpanAttrs) (context.Context, iinfra.Span) {
+			return ctx, span
+		}).AnyTimes()
+	tracer.EXPECT().Extract(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string) context.Context {
+			return ctx
+		}).AnyTimes()
+	return tracer
+}
+
+// anyMetrics accepts every measure
+func anyMetrics(ctrl *gomock.Controller) iinfra.MetricsProvider {
+	metrics := mock_iinfra.NewMockMetricsProvider(ctrl)
+	metrics.EXPECT().Counter(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	metrics.EXPECT().Histogram(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	metrics.EXPECT().Gauge(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	return metrics
 }
@@ -0,0 +1,66 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package iinfra
+
+import (
+	"context"
+	"errors"
+)
+
+// contextKey is unexported, so no other package can collide with the values kept by iinfra
+type contextKey int
+
+const (
+	contextKeyTx contextKey = iota
+	contextKeyTxRequired
+	contextKeyLogAttrs
+)
+
+// ErrTxRequired is returned by the gateway calls made without a transaction in a context that requires it
+var ErrTxRequired = errors.New("the call must be made inside of a transaction")
+
+// ContextWithTx returns a copy of ctx that carries tx to the gateways
+func ContextWithTx(ctx context.Context, tx Tx) context.Context {
+	return context.WithValue(ctx, contextKeyTx, tx)
+}
+
+// TxFromContext gets the transaction carried by ctx, if any
+func TxFromContext(ctx context.Context) (Tx, bool) {
+	tx := ctx.Value(contextKeyTx)
+	return tx, tx != nil
+}
+
+// WithTxRequired returns a copy of ctx in which the gateway calls made outside of a transaction fail
+// with ErrTxRequired, instead of running on their own
+func WithTxRequired(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyTxRequired, true)
+}
+
+// TxRequired checks if the gateway calls made with ctx must be inside of a transaction
+func TxRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(contextKeyTxRequired).(bool)
+	return required
+}
+
+// WithLogAttrs returns a copy of ctx whose log attrs are the ones of ctx merged with attrs, that win
+// when both have the same key. The attrs of ctx are left untouched
+func WithLogAttrs(ctx context.Context, attrs LogAttrs) context.Context {
+	parent := LogAttrsFromContext(ctx)
+	merged := make(LogAttrs, len(parent)+len(attrs))
+	for key, value := range parent {
+		merged[key] = value
+	}
+	for key, value := range attrs {
+		merged[key] = value
+	}
+
+	return context.WithValue(ctx, contextKeyLogAttrs, merged)
+}
+
+// LogAttrsFromContext gets the attrs that every log made with ctx must have, they must not be changed
+func LogAttrsFromContext(ctx context.Context) LogAttrs {
+	attrs, _ := ctx.Value(contextKeyLogAttrs).(LogAttrs)
+	return attrs
+}
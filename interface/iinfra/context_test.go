@@ -0,0 +1,44 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package iinfra
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	t.Run("should carry the tx", func(t *testing.T) {
+		_, ok := TxFromContext(context.Background())
+		assert.False(t, ok)
+
+		tx, ok := TxFromContext(ContextWithTx(context.Background(), "fake-tx"))
+		assert.True(t, ok)
+		assert.Equal(t, "fake-tx", tx)
+	})
+
+	t.Run("should not collide with the string keys of other packages", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), "ContextKeyTx", "fake-tx")
+		_, ok := TxFromContext(ctx)
+		assert.False(t, ok)
+	})
+
+	t.Run("should only require the tx when asked to", func(t *testing.T) {
+		assert.False(t, TxRequired(context.Background()))
+		assert.True(t, TxRequired(WithTxRequired(context.Background())))
+	})
+
+	t.Run("should merge the log attrs without changing the ones of the parent context", func(t *testing.T) {
+		assert.Nil(t, LogAttrsFromContext(context.Background()))
+
+		parent := WithLogAttrs(context.Background(), LogAttrs{"request-id": "1", "user-id": "2"})
+		child := WithLogAttrs(parent, LogAttrs{"user-id": "3", "tx": true})
+
+		assert.Equal(t, LogAttrs{"request-id": "1", "user-id": "2"}, LogAttrsFromContext(parent))
+		assert.Equal(t, LogAttrs{"request-id": "1", "user-id": "3", "tx": true}, LogAttrsFromContext(child))
+	})
+}
@@ -7,26 +7,66 @@ package iinfra
 import (
 	"context"
 	"database/sql"
+	"errors"
 )
 
-// ContextKeyTx ...
-const ContextKeyTx string = "ContextKeyTx"
+// SQL dialects that the gateways know how to speak
+const (
+	DialectSQLite3  Dialect = "sqlite3"
+	DialectPostgres Dialect = "postgres"
+	DialectMySQL    Dialect = "mysql"
+)
+
+// ErrUniqueViolation is wrapped by the adapters when a statement violates an unique constraint
+var ErrUniqueViolation = errors.New("unique constraint violation")
+
+// ErrSavepointUnsupported is returned by the Savepointer methods when the transaction can't have savepoints,
+// so the partial rollback of a nested transaction never goes unnoticed
+var ErrSavepointUnsupported = errors.New("the transaction doesn't support savepoints")
 
 type (
 	// Tx ...
 	Tx interface{}
 
+	// Dialect ...
+	Dialect string
+
 	// Session ...
 	Session interface {
-		BeginTx() (Tx, error)
+		// BeginTx starts a transaction that is rolled back if ctx is done before the commit,
+		// opts can be nil to use the defaults of the database
+		BeginTx(ctx context.Context, opts *sql.TxOptions) (Tx, error)
 		CommitTx(tx Tx) error
 		RollbackTx(tx Tx) error
 	}
 
+	// Savepointer is implemented by the sessions that can partially roll back a transaction,
+	// the names are chosen by the caller and are valid SQL identifiers
+	Savepointer interface {
+		Savepoint(ctx context.Context, tx Tx, name string) error
+		RollbackToSavepoint(ctx context.Context, tx Tx, name string) error
+		ReleaseSavepoint(ctx context.Context, tx Tx, name string) error
+	}
+
+	// UnitOfWork runs a function inside a transaction, that is committed when the function returns
+	// nil and rolled back when it returns an error or panics. The ctx given to the function carries
+	// the transaction to the gateways, and a call made with it runs in a savepoint of that transaction
+	UnitOfWork interface {
+		WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+		// WithinTxOptions is WithinTx with the options of a new transaction, a nested call
+		// inherits the options of the outer one
+		WithinTxOptions(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error
+	}
+
 	// Database ...
 	Database interface {
 		Session
+		Dialect() Dialect
 		Query(context.Context, string, ...interface{}) (*sql.Rows, error)
 		Exec(context.Context, string, ...interface{}) (sql.Result, error)
+		// Ping checks if the database can be reached, opening a connection if needed
+		Ping(ctx context.Context) error
+		// Close releases the connections, it must be called once nothing uses the database anymore
+		Close() error
 	}
 )
@@ -0,0 +1,15 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package iinfra
+
+import "context"
+
+// HealthChecker checks a dependency that the application needs to serve the requests, ex: the database.
+// Every adapter can provide its own, which is reported by its name in the readiness of the application
+type HealthChecker interface {
+	Name() string
+	// Check returns nil when the dependency can be used, it must honor the deadline of ctx
+	Check(ctx context.Context) error
+}
@@ -6,9 +6,6 @@ package iinfra
 
 import "context"
 
-// ContextKeyGlobalLogAttrs ...
-const ContextKeyGlobalLogAttrs string = "ContextKeyGlobalLogAttrs"
-
 // LogProvider ...
 type (
 	LogProvider interface {
@@ -18,6 +15,12 @@ type (
 		Warn(ctx context.Context, message string, attrs ...LogAttrs)
 	}
 
+	// LogFlusher is implemented by the log providers that write asynchronously, Flush waits for
+	// the pending logs to be written
+	LogFlusher interface {
+		Flush()
+	}
+
 	// LogAttrs ...
 	LogAttrs map[string]interface{}
 )
@@ -0,0 +1,22 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package iinfra
+
+// MetricsProvider aggregates the measures of the application. A metric is identified by its name, which
+// must always be used with the same kind and label names, and each set of label values is a series of it.
+// The names follow the Prometheus conventions, ex: http_requests_total and http_request_duration_seconds
+type (
+	MetricsProvider interface {
+		// Counter adds the value, which cannot be negative, to the counter
+		Counter(name string, labels MetricLabels, value float64)
+		// Histogram counts the value in the buckets of the histogram, the durations are in seconds
+		Histogram(name string, labels MetricLabels, value float64)
+		// Gauge sets the value of the gauge
+		Gauge(name string, labels MetricLabels, value float64)
+	}
+
+	// MetricLabels ...
+	MetricLabels map[string]string
+)
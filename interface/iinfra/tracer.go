@@ -0,0 +1,35 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package iinfra
+
+import "context"
+
+// Tracer starts the spans of the traces. The span of the context is the parent of the new one, which is
+// put in the returned context, so the spans started with it are its children. The spans follow the
+// OpenTelemetry model and the traces are propagated by the W3C traceparent header
+type (
+	Tracer interface {
+		// Start starts a span named after the operation, ex: POST /user or gateway.user.create. The
+		// attrs can be nil
+		Start(ctx context.Context, name string, attrs SpanAttrs) (context.Context, Span)
+		// Extract continues the trace of the W3C traceparent header, the spans started with the returned
+		// context are children of the remote span. An empty or invalid header starts a new trace
+		Extract(ctx context.Context, traceparent string) context.Context
+	}
+
+	// Span is an operation of a trace, it's exported when it ends
+	Span interface {
+		SetAttrs(attrs SpanAttrs)
+		// RecordError sets the status of the span to error
+		RecordError(err error)
+		// End ends the span, the calls after the first one are ignored
+		End()
+		// TraceID is the hex ID of the trace of the span, so the logs can be tied to it
+		TraceID() string
+	}
+
+	// SpanAttrs are named after the OpenTelemetry semantic conventions when there is one, ex: db.statement
+	SpanAttrs map[string]interface{}
+)
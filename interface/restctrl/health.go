@@ -0,0 +1,121 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package restctrl
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+)
+
+// healthCheckTimeout bounds each check, the probes of the orchestrators give up after a few seconds
+const healthCheckTimeout = 2 * time.Second
+
+// Health statuses of the application and of its dependencies
+const (
+	healthStatusOK          = "ok"
+	healthStatusUnavailable = "unavailable"
+)
+
+// Health ...
+type (
+	Health interface {
+		// Live tells if the process is alive, it checks no dependency
+		Live(req RestRequest) RestResponse
+		// Ready tells if the dependencies can be used, so the requests can be served
+		Ready(req RestRequest) RestResponse
+	}
+
+	health struct {
+		checkers []iinfra.HealthChecker
+		logger   iinfra.LogProvider
+	}
+
+	// health response body, Checks has the status of each dependency by its name. The errors of the
+	// checks are only logged, the probes aren't authenticated and the errors can tell about the database
+	healthResBody struct {
+		Status string                  `json:"status"`
+		Checks map[string]checkResBody `json:"checks,omitempty"`
+	}
+
+	checkResBody struct {
+		Status   string `json:"status"`
+		Duration string `json:"duration"`
+	}
+)
+
+// NewHealth checks the readiness with the checkers, which are run concurrently
+func NewHealth(checkers []iinfra.HealthChecker, logger iinfra.LogProvider) Health {
+	return health{
+		checkers: checkers,
+		logger:   logger,
+	}
+}
+
+// Live ...
+func (h health) Live(RestRequest) RestResponse {
+	return respondJSON(http.StatusOK, healthResBody{Status: healthStatusOK})
+}
+
+// Ready ...
+func (h health) Ready(req RestRequest) RestResponse {
+	startTime := time.Now()
+	ctx := req.newContext()
+	h.logger.Debug(ctx, "starting ready health")
+
+	resBody := healthResBody{
+		Status: healthStatusOK,
+		Checks: make(map[string]checkResBody, len(h.checkers)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, checker := range h.checkers {
+		wg.Add(1)
+		go func(checker iinfra.HealthChecker) {
+			defer wg.Done()
+			check := h.check(ctx, checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+			resBody.Checks[checker.Name()] = check
+			if check.Status != healthStatusOK {
+				resBody.Status = healthStatusUnavailable
+			}
+		}(checker)
+	}
+	wg.Wait()
+
+	statusCode := http.StatusOK
+	if resBody.Status != healthStatusOK {
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	h.logger.Debug(ctx, "ending ready health", iinfra.LogAttrs{"duration": time.Since(startTime).String()})
+	return respondJSON(statusCode, resBody)
+}
+
+// check runs the checker within the health check timeout
+func (h health) check(ctx context.Context, checker iinfra.HealthChecker) (res checkResBody) {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	startTime := time.Now()
+	err := checker.Check(ctx)
+	res.Duration = time.Since(startTime).String()
+
+	if err != nil {
+		h.logger.Error(ctx, fmt.Sprintf("error when checking %s: %v", checker.Name(), err))
+		res.Status = healthStatusUnavailable
+		return
+	}
+
+	res.Status = healthStatusOK
+	return
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package restctrl
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/interface/iinfra/mock_iinfra"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthLive(t *testing.T) {
+	t.Run("should be ok without checking the dependencies", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		c := NewHealth([]iinfra.HealthChecker{mock_iinfra.NewMockHealthChecker(ctrl)}, nil)
+		res := c.Live(RestRequest{})
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `{"status":"ok"}`, string(res.Body))
+	})
+}
+
+func TestHealthReady(t *testing.T) {
+	checker := func(ctrl *gomock.Controller, name string, err error) iinfra.HealthChecker {
+		c := mock_iinfra.NewMockHealthChecker(ctrl)
+		c.EXPECT().Name().Return(name).AnyTimes()
+		c.EXPECT().Check(gomock.Any()).Return(err)
+		return c
+	}
+
+	t.Run("should be ok when every dependency is ok", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		c := NewHealth([]iinfra.HealthChecker{
+			checker(ctrl, "database", nil),
+			checker(ctrl, "migrations", nil),
+		}, logger)
+		res := c.Ready(RestRequest{})
+
+		var resBody healthResBody
+		require.NoError(t, json.Unmarshal(res.Body, &resBody))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, contentTypeJSON, res.ContentType)
+		assert.Equal(t, healthStatusOK, resBody.Status)
+		assert.Equal(t, healthStatusOK, resBody.Checks["database"].Status)
+		assert.Equal(t, healthStatusOK, resBody.Checks["migrations"].Status)
+	})
+
+	t.Run("should be unavailable when a dependency fails, with the status of each one but not the error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		logger.EXPECT().Error(gomock.Any(), "error when checking migrations: fake-error")
+
+		c := NewHealth([]iinfra.HealthChecker{
+			checker(ctrl, "database", nil),
+			checker(ctrl, "migrations", errors.New("fake-error")),
+		}, logger)
+		res := c.Ready(RestRequest{})
+
+		var resBody healthResBody
+		require.NoError(t, json.Unmarshal(res.Body, &resBody))
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, healthStatusUnavailable, resBody.Status)
+		assert.Equal(t, healthStatusOK, resBody.Checks["database"].Status)
+		assert.Equal(t, healthStatusUnavailable, resBody.Checks["migrations"].Status)
+		assert.NotContains(t, string(res.Body), "fake-error")
+	})
+
+	t.Run("should be ok when there is no dependency", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		res := NewHealth(nil, logger).Ready(RestRequest{})
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `{"status":"ok"}`, string(res.Body))
+	})
+}
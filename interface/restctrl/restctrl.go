@@ -5,40 +5,163 @@
 package restctrl
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"net/http"
 
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/google/uuid"
 )
 
+// Content types of the response bodies
+const (
+	contentTypeJSON    = "application/json"
+	contentTypeProblem = "application/problem+json"
+)
+
+// codeInternal is the code of the errors that aren't business ones, their details are only logged
+const codeInternal = "ErrInternal"
+
+// metricInteractorExecutions counts the executions of the interactors by outcome, which is the code of
+// the business error, codeInternal for the other errors or ok
+const metricInteractorExecutions = "interactor_executions_total"
+
+// problemTypePrefix prefixes the code of the error in the type of the problem, a relative URI that
+// identifies the problem and isn't meant to be dereferenced
+const problemTypePrefix = "/problems/"
+
+// categoryStatus is the HTTP status of each category of business error
+var categoryStatus = map[businesserr.Category]int{
+	businesserr.CategoryMalformed:          http.StatusBadRequest,          // 400
+	businesserr.CategoryValidation:         http.StatusUnprocessableEntity, // 422
+	businesserr.CategoryNotFound:           http.StatusNotFound,            // 404
+	businesserr.CategoryConflict:           http.StatusConflict,            // 409
+	businesserr.CategoryForbidden:          http.StatusForbidden,           // 403
+	businesserr.CategoryPreconditionFailed: http.StatusPreconditionFailed,  // 412
+	businesserr.CategoryRateLimited:        http.StatusTooManyRequests,     // 429
+}
+
 // RestRequest ...
 type (
 	RestRequest struct {
-		GetQueryParam func(key string) string
-		Body          []byte
+		// Context is done when the request is cancelled or times out, nil means no deadline
+		Context           context.Context
+		GetQueryParam     func(key string) string
+		GetQueryParamKeys func() []string
+		GetPathParam      func(key string) string
+		Body              []byte
 	}
 
 	// RestResponse ...
 	RestResponse struct {
-		Body       []byte
-		StatusCode int
+		Body        []byte
+		StatusCode  int
+		ContentType string // empty when there is no body
 	}
+
+	// problemResBody is the RFC 7807 body of the error responses, Code is the stable code of the
+	// business error that clients can rely on
+	problemResBody struct {
+		Type       string                 `json:"type"`
+		Title      string                 `json:"title"`
+		Status     int                    `json:"status"`
+		Detail     string                 `json:"detail,omitempty"`
+		Instance   string                 `json:"instance,omitempty"`
+		Code       string                 `json:"code"`
+		Details    map[string]interface{} `json:"details,omitempty"`
+		Violations []violationResBody     `json:"violations,omitempty"`
+	}
+
+	// violation of a field of the request, the problem of a validation error has a list of them
+	violationResBody struct {
+		Field   string                 `json:"field"`
+		Code    string                 `json:"code"`
+		Message string                 `json:"message"`
+		Params  map[string]interface{} `json:"params,omitempty"`
+	}
+
+	// requestIDKey is the context key of the ID of the request
+	requestIDKey struct{}
 )
 
-func respondError(err error) (res RestResponse) {
-	if be, ok := err.(businesserr.BusinessError); ok {
-		res.Body = []byte(be.Error())
-		switch be {
-		case businesserr.ErrCreateUserNotFound:
-			res.StatusCode = http.StatusNotFound
-		default:
-			res.StatusCode = http.StatusBadRequest
+// requestContext is the context that every call made by the request must honor
+func (r RestRequest) requestContext() context.Context {
+	if r.Context == nil {
+		return context.Background()
+	}
+	return r.Context
+}
+
+// newContext is the request context with a new request ID, which is logged with every message and
+// sent with the errors
+func (r RestRequest) newContext() context.Context {
+	id := uuid.New().String()
+	ctx := context.WithValue(r.requestContext(), requestIDKey{}, id)
+	return iinfra.WithLogAttrs(ctx, iinfra.LogAttrs{"request-id": id})
+}
+
+// requestID is the ID that newContext put in the context, if any
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func respondJSON(statusCode int, body interface{}) (res RestResponse) {
+	res.Body, _ = json.Marshal(body)
+	res.StatusCode = statusCode
+	res.ContentType = contentTypeJSON
+	return
+}
+
+// outcome is the code of the business error, codeInternal for the other errors, or ok without error
+func outcome(err error) string {
+	if err == nil {
+		return "ok"
+	}
+
+	var be businesserr.BusinessError
+	if errors.As(err, &be) {
+		return be.Code()
+	}
+	return codeInternal
+}
+
+// respondError renders the error as an RFC 7807 problem, the request ID of the context is its instance
+func respondError(ctx context.Context, err error) (res RestResponse) {
+	problem := problemResBody{
+		Instance: requestID(ctx),
+	}
+
+	// the business error can be wrapped, with a cause that is only logged
+	var be businesserr.BusinessError
+	if errors.As(err, &be) {
+		var ok bool
+		problem.Code = be.Code()
+		problem.Detail = be.Error()
+		problem.Details = businesserr.Details(err)
+		problem.Status, ok = categoryStatus[be.Category()]
+		if !ok {
+			problem.Status = http.StatusBadRequest
 		}
 
-		return
+		var ve businesserr.ValidationError
+		if errors.As(err, &ve) {
+			for _, violation := range ve.Violations {
+				problem.Violations = append(problem.Violations, violationResBody(violation))
+			}
+		}
+	} else {
+		problem.Status = http.StatusInternalServerError
+		problem.Code = codeInternal
 	}
 
-	res.Body = []byte("internal server error")
-	res.StatusCode = http.StatusInternalServerError
+	problem.Type = problemTypePrefix + problem.Code
+	problem.Title = http.StatusText(problem.Status)
 
+	res.Body, _ = json.Marshal(problem)
+	res.StatusCode = problem.Status
+	res.ContentType = contentTypeProblem
 	return
 }
@@ -5,27 +5,160 @@
 package restctrl
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"testing"
 
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRespondError(t *testing.T) {
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "fake-request-id")
+
 	t.Run("should results StatusNotFound when receive ErrCreateUserNotFound", func(t *testing.T) {
-		res := respondError(businesserr.ErrCreateUserNotFound)
+		res := respondError(ctx, businesserr.ErrCreateUserNotFound)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results StatusNotFound when receive ErrUpdateUserNotFound", func(t *testing.T) {
+		res := respondError(ctx, businesserr.ErrUpdateUserNotFound)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results StatusNotFound when receive ErrGetUserNotFound", func(t *testing.T) {
+		res := respondError(ctx, businesserr.ErrGetUserNotFound)
 		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 	})
 
-	t.Run("should results StatusBadRequest when receive a business error", func(t *testing.T) {
-		res := respondError(businesserr.ErrCreateUserErrEmptyEmail)
+	t.Run("should results StatusConflict with the problem of the business error", func(t *testing.T) {
+		res := respondError(ctx, businesserr.ErrCreateUserAlreadyExists)
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.Equal(t, "application/problem+json", res.ContentType)
+		assert.JSONEq(t, `{
+			"type": "/problems/ErrCreateUserAlreadyExists",
+			"title": "Conflict",
+			"status": 409,
+			"detail": "user already exists",
+			"instance": "fake-request-id",
+			"code": "ErrCreateUserAlreadyExists"
+		}`, string(res.Body))
+	})
+
+	t.Run("should find the business error through the wrapping, without telling its cause", func(t *testing.T) {
+		err := fmt.Errorf("update user: %w", businesserr.Wrap(businesserr.ErrUpdateUserAlreadyExists,
+			errors.New("fake cause"), map[string]interface{}{"field": "email"}))
+
+		res := respondError(ctx, err)
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+		assert.JSONEq(t, `{
+			"type": "/problems/ErrUpdateUserAlreadyExists",
+			"title": "Conflict",
+			"status": 409,
+			"detail": "user already exists",
+			"instance": "fake-request-id",
+			"code": "ErrUpdateUserAlreadyExists",
+			"details": {"field": "email"}
+		}`, string(res.Body))
+	})
+
+	t.Run("should find the violations of a wrapped validation error", func(t *testing.T) {
+		var violations businesserr.Violations
+		violations.Add("name", businesserr.ViolationRequired, "user name cannot be empty", nil)
+
+		res := respondError(ctx, fmt.Errorf("validate: %w", violations.Err()))
+
+		var resBody problemResBody
+		assert.NoError(t, json.Unmarshal(res.Body, &resBody))
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Len(t, resBody.Violations, 1)
+	})
+
+	t.Run("should results StatusBadRequest when receive a malformed business error", func(t *testing.T) {
+		res := respondError(ctx, businesserr.ErrSearchUserInvalidLimit)
 		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 	})
 
-	t.Run("should results StatusInternalServerError when receive an unknown error", func(t *testing.T) {
-		res := respondError(errors.New("fake error"))
+	t.Run("should results StatusUnprocessableEntity with every violation when receive a validation error", func(t *testing.T) {
+		var violations businesserr.Violations
+		violations.Add("name", businesserr.ViolationRequired, "user name cannot be empty", nil)
+		violations.Add("email", "too_long", "user email is too long", map[string]interface{}{"max": 254})
+
+		res := respondError(ctx, violations.Err())
+
+		assert.Equal(t, http.StatusUnprocessableEntity, res.StatusCode)
+		assert.Equal(t, "application/problem+json", res.ContentType)
+		assert.JSONEq(t, `{
+			"type": "/problems/ErrValidation",
+			"title": "Unprocessable Entity",
+			"status": 422,
+			"detail": "invalid fields: name: user name cannot be empty; email: user email is too long",
+			"instance": "fake-request-id",
+			"code": "ErrValidation",
+			"violations": [
+				{"field": "name", "code": "required", "message": "user name cannot be empty"},
+				{"field": "email", "code": "too_long", "message": "user email is too long", "params": {"max": 254}}
+			]
+		}`, string(res.Body))
+	})
+
+	t.Run("should results StatusInternalServerError without the details when receive an unknown error", func(t *testing.T) {
+		res := respondError(ctx, errors.New("fake error"))
+
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, "application/problem+json", res.ContentType)
+		assert.JSONEq(t, `{
+			"type": "/problems/ErrInternal",
+			"title": "Internal Server Error",
+			"status": 500,
+			"instance": "fake-request-id",
+			"code": "ErrInternal"
+		}`, string(res.Body))
+	})
+}
+
+func TestCategoryStatus(t *testing.T) {
+	t.Run("should map every category of business error to an HTTP status", func(t *testing.T) {
+		for _, category := range businesserr.Categories {
+			assert.Contains(t, categoryStatus, category)
+		}
+	})
+}
+
+func TestRestRequestNewContext(t *testing.T) {
+	t.Run("should give every request its own ID", func(t *testing.T) {
+		first := requestID(RestRequest{}.newContext())
+		second := requestID(RestRequest{}.newContext())
+
+		require.NotEmpty(t, first)
+		assert.NotEqual(t, first, second)
+	})
+}
+
+func TestRespondJSON(t *testing.T) {
+	t.Run("should marshal the body as JSON", func(t *testing.T) {
+		res := respondJSON(http.StatusOK, map[string]string{"id": "1"})
+
+		var resBody map[string]string
+		assert.NoError(t, json.Unmarshal(res.Body, &resBody))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.ContentType)
+		assert.Equal(t, map[string]string{"id": "1"}, resBody)
+	})
+}
+
+func TestOutcome(t *testing.T) {
+	t.Run("should be the code of the business error, even when wrapped", func(t *testing.T) {
+		assert.Equal(t, "ok", outcome(nil))
+		assert.Equal(t, businesserr.ErrGetUserNotFound.Code(), outcome(businesserr.ErrGetUserNotFound))
+		assert.Equal(t, businesserr.ErrGetUserNotFound.Code(),
+			outcome(businesserr.Wrap(businesserr.ErrGetUserNotFound, errors.New("fake-error"), nil)))
+		assert.Equal(t, codeInternal, outcome(errors.New("fake-error")))
 	})
 }
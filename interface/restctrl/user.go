@@ -6,15 +6,17 @@ package restctrl
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/dougefr/go-clean-arch/interface/iinfra"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
 	"github.com/dougefr/go-clean-arch/usecase/interactor"
-	"github.com/google/uuid"
 )
 
 // User ...
@@ -22,13 +24,24 @@ type (
 	User interface {
 		Create(req RestRequest) RestResponse
 		Search(req RestRequest) RestResponse
+		Get(req RestRequest) RestResponse
+		Update(req RestRequest) RestResponse
+		Patch(req RestRequest) RestResponse
+		Delete(req RestRequest) RestResponse
+		Restore(req RestRequest) RestResponse
 	}
 
 	user struct {
-		ucCreateUser interactor.CreateUser
-		ucSearchUser interactor.SearchUser
-		session      iinfra.Session
-		logger       iinfra.LogProvider
+		ucCreateUser  interactor.CreateUser
+		ucSearchUser  interactor.SearchUser
+		ucUpdateUser  interactor.UpdateUser
+		ucDeleteUser  interactor.DeleteUser
+		ucRestoreUser interactor.RestoreUser
+		ucGetUser     interactor.GetUser
+		uow           iinfra.UnitOfWork
+		logger        iinfra.LogProvider
+		metrics       iinfra.MetricsProvider
+		tracer        iinfra.Tracer
 	}
 
 	// create user request body
@@ -44,39 +57,89 @@ type (
 		Email string `json:"email"`
 	}
 
+	// get user response body
+	getResBody struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	// update user request body, all fields are required
+	updateReqBody struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	// patch user request body, absent fields are kept as they are
+	patchReqBody struct {
+		Name  *string `json:"name"`
+		Email *string `json:"email"`
+	}
+
+	// update user response body
+	updateResBody struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
+	// restore user response body
+	restoreResBody struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+
 	// search user response body
 	searchResBody struct {
+		Users      []searchResBodyUser `json:"users"`
+		NextCursor string              `json:"next_cursor,omitempty"`
+		Total      int64               `json:"total"`
+	}
+
+	// user of the search user response body
+	searchResBodyUser struct {
 		ID    string `json:"id"`
 		Name  string `json:"name"`
 		Email string `json:"email"`
 	}
 )
 
-// NewUser ...
+// NewUser traces the execution of each interactor, the span of the request must be in its context
 func NewUser(ucCreateUser interactor.CreateUser,
 	ucSearchUser interactor.SearchUser,
-	session iinfra.Session,
-	logger iinfra.LogProvider) User {
+	ucUpdateUser interactor.UpdateUser,
+	ucDeleteUser interactor.DeleteUser,
+	ucRestoreUser interactor.RestoreUser,
+	ucGetUser interactor.GetUser,
+	uow iinfra.UnitOfWork,
+	logger iinfra.LogProvider,
+	metrics iinfra.MetricsProvider,
+	tracer iinfra.Tracer) User {
 	return user{
-		ucCreateUser: ucCreateUser,
-		ucSearchUser: ucSearchUser,
-		session:      session,
-		logger:       logger,
+		ucCreateUser:  ucCreateUser,
+		ucSearchUser:  ucSearchUser,
+		ucUpdateUser:  ucUpdateUser,
+		ucDeleteUser:  ucDeleteUser,
+		ucRestoreUser: ucRestoreUser,
+		ucGetUser:     ucGetUser,
+		uow:           uow,
+		logger:        logger,
+		metrics:       metrics,
+		tracer:        tracer,
 	}
 }
 
 // Create ...
 func (u user) Create(req RestRequest) (res RestResponse) {
 	startTime := time.Now()
-	ctx := context.WithValue(context.Background(), iinfra.ContextKeyGlobalLogAttrs, iinfra.LogAttrs{
-		"request-id": uuid.New(),
-	})
+	ctx := req.newContext()
 	u.logger.Debug(ctx, "starting create user")
 
 	var reqBody createReqBody
 	if err := json.Unmarshal(req.Body, &reqBody); err != nil {
 		u.logger.Error(ctx, fmt.Sprintf("error when unmarshalling request body: %v", err))
-		return respondError(err)
+		return respondError(ctx, businesserr.Wrap(businesserr.ErrMalformedBody, err, nil))
 	}
 
 	ucReqModel := interactor.CreateUserRequestModel{
@@ -84,32 +147,25 @@ func (u user) Create(req RestRequest) (res RestResponse) {
 		Email: reqBody.Email,
 	}
 
-	tx, err := u.session.BeginTx()
-	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf("error when starting tx: %v", err))
-		return respondError(err)
-	}
-
-	ctx = context.WithValue(ctx, iinfra.ContextKeyTx, tx) // add Tx to context to be use in gateways
-	ucResModel, err := u.ucCreateUser.Execute(ctx, ucReqModel)
+	ctx, span := u.trace(ctx, "create_user")
+	var ucResModel interactor.CreateUserResponseModel
+	err := u.uow.WithinTx(ctx, func(ctx context.Context) (err error) {
+		ucResModel, err = u.ucCreateUser.Execute(ctx, ucReqModel)
+		return
+	})
+	u.observe(span, "create_user", err)
 	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %v", err))
-		_ = u.session.RollbackTx(tx)
-		return respondError(err)
+		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %+v", err))
+		return respondError(ctx, err)
 	}
 
+	// the response is only built after the commit, so it never tells about data that wasn't saved
 	var resBody createResBody
 	resBody.ID = strconv.FormatInt(ucResModel.ID, 10) // format to string because int64 can be too big to JS
 	resBody.Name = ucResModel.Name
 	resBody.Email = ucResModel.Email
 
-	res.Body, _ = json.Marshal(resBody)
-	res.StatusCode = http.StatusCreated // 201
-
-	if err = u.session.CommitTx(tx); err != nil {
-		u.logger.Error(ctx, fmt.Sprintf("error when commiting tx: %v", err))
-		return respondError(err)
-	}
+	res = respondJSON(http.StatusCreated, resBody) // 201
 
 	u.logger.Debug(ctx, "ending create user method", iinfra.LogAttrs{
 		"duration": time.Since(startTime),
@@ -121,32 +177,43 @@ func (u user) Create(req RestRequest) (res RestResponse) {
 // Search ...
 func (u user) Search(req RestRequest) (res RestResponse) {
 	startTime := time.Now()
-	ctx := context.WithValue(context.Background(), iinfra.ContextKeyGlobalLogAttrs, iinfra.LogAttrs{
-		"request-id": uuid.New(),
-	})
+	ctx := req.newContext()
 	u.logger.Debug(ctx, "starting create user")
 
-	// get filters from query param
-	var filter interactor.SearchUserRequestModel
-	filter.Email = req.GetQueryParam("email")
+	filter, err := searchFilterFromQuery(req)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when parsing query params: %v", err))
+		return respondError(ctx, err)
+	}
 
-	ucResModel, err := u.ucSearchUser.Execute(ctx, filter)
+	// the page and the total must be read from the same snapshot
+	ctx, span := u.trace(ctx, "search_user")
+	var ucResModel interactor.SearchUserResponseModel
+	txOpts := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	err = u.uow.WithinTxOptions(ctx, txOpts, func(ctx context.Context) (err error) {
+		ucResModel, err = u.ucSearchUser.Execute(ctx, filter)
+		return
+	})
+	u.observe(span, "search_user", err)
 	if err != nil {
-		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %v", err))
-		return respondError(err)
+		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %+v", err))
+		return respondError(ctx, err)
 	}
 
-	var resBody []searchResBody
+	resBody := searchResBody{
+		Users:      make([]searchResBodyUser, 0, len(ucResModel.Users)),
+		NextCursor: ucResModel.NextCursor,
+		Total:      ucResModel.Total,
+	}
 	for _, modelUser := range ucResModel.Users {
-		resBody = append(resBody, searchResBody{
+		resBody.Users = append(resBody.Users, searchResBodyUser{
 			ID:    strconv.FormatInt(modelUser.ID, 10),
 			Name:  modelUser.Name,
 			Email: modelUser.Email,
 		})
 	}
 
-	res.Body, _ = json.Marshal(resBody)
-	res.StatusCode = http.StatusOK
+	res = respondJSON(http.StatusOK, resBody)
 
 	u.logger.Debug(ctx, "ending create user method", iinfra.LogAttrs{
 		"duration": time.Since(startTime),
@@ -154,3 +221,299 @@ func (u user) Search(req RestRequest) (res RestResponse) {
 
 	return
 }
+
+// Get ...
+func (u user) Get(req RestRequest) (res RestResponse) {
+	startTime := time.Now()
+	ctx := req.newContext()
+	u.logger.Debug(ctx, "starting get user")
+
+	id, err := pathUserID(req)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when parsing user id: %v", err))
+		return respondError(ctx, businesserr.ErrGetUserNotFound)
+	}
+
+	// a read-only transaction, so the reads are made inside of one even when the context requires it
+	ctx, span := u.trace(ctx, "get_user")
+	var ucResModel interactor.GetUserResponseModel
+	err = u.uow.WithinTxOptions(ctx, &sql.TxOptions{ReadOnly: true}, func(ctx context.Context) (err error) {
+		ucResModel, err = u.ucGetUser.Execute(ctx, interactor.GetUserRequestModel{ID: id})
+		return
+	})
+	u.observe(span, "get_user", err)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %+v", err))
+		return respondError(ctx, err)
+	}
+
+	var resBody getResBody
+	resBody.ID = strconv.FormatInt(ucResModel.ID, 10) // format to string because int64 can be too big to JS
+	resBody.Name = ucResModel.Name
+	resBody.Email = ucResModel.Email
+
+	res = respondJSON(http.StatusOK, resBody)
+
+	u.logger.Debug(ctx, "ending get user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Update ...
+func (u user) Update(req RestRequest) (res RestResponse) {
+	startTime := time.Now()
+	ctx := req.newContext()
+	u.logger.Debug(ctx, "starting update user")
+
+	var reqBody updateReqBody
+	if err := json.Unmarshal(req.Body, &reqBody); err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when unmarshalling request body: %v", err))
+		return respondError(ctx, businesserr.Wrap(businesserr.ErrMalformedBody, err, nil))
+	}
+
+	// a full replace informs every field, even the empty ones
+	res = u.update(ctx, req, interactor.UpdateUserRequestModel{
+		Name:  &reqBody.Name,
+		Email: &reqBody.Email,
+	})
+
+	u.logger.Debug(ctx, "ending update user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Patch ...
+func (u user) Patch(req RestRequest) (res RestResponse) {
+	startTime := time.Now()
+	ctx := req.newContext()
+	u.logger.Debug(ctx, "starting patch user")
+
+	var reqBody patchReqBody
+	if err := json.Unmarshal(req.Body, &reqBody); err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when unmarshalling request body: %v", err))
+		return respondError(ctx, businesserr.Wrap(businesserr.ErrMalformedBody, err, nil))
+	}
+
+	res = u.update(ctx, req, interactor.UpdateUserRequestModel{
+		Name:  reqBody.Name,
+		Email: reqBody.Email,
+	})
+
+	u.logger.Debug(ctx, "ending patch user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// update executes the update user core inside a Tx, used by both PUT and PATCH
+func (u user) update(ctx context.Context, req RestRequest,
+	ucReqModel interactor.UpdateUserRequestModel) (res RestResponse) {
+	id, err := pathUserID(req)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when parsing user id: %v", err))
+		return respondError(ctx, businesserr.ErrUpdateUserNotFound)
+	}
+	ucReqModel.ID = id
+
+	ctx, span := u.trace(ctx, "update_user")
+	var ucResModel interactor.UpdateUserResponseModel
+	err = u.uow.WithinTx(ctx, func(ctx context.Context) (err error) {
+		ucResModel, err = u.ucUpdateUser.Execute(ctx, ucReqModel)
+		return
+	})
+	u.observe(span, "update_user", err)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %+v", err))
+		return respondError(ctx, err)
+	}
+
+	var resBody updateResBody
+	resBody.ID = strconv.FormatInt(ucResModel.ID, 10) // format to string because int64 can be too big to JS
+	resBody.Name = ucResModel.Name
+	resBody.Email = ucResModel.Email
+
+	res = respondJSON(http.StatusOK, resBody)
+
+	return
+}
+
+// Delete ...
+func (u user) Delete(req RestRequest) (res RestResponse) {
+	startTime := time.Now()
+	ctx := req.newContext()
+	u.logger.Debug(ctx, "starting delete user")
+
+	id, err := pathUserID(req)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when parsing user id: %v", err))
+		return respondError(ctx, businesserr.ErrDeleteUserNotFound)
+	}
+
+	ucReqModel := interactor.DeleteUserRequestModel{
+		ID:    id,
+		Purge: req.GetQueryParam("purge") == "true", // erasure requests must ask for it explicitly
+	}
+
+	ctx, span := u.trace(ctx, "delete_user")
+	err = u.uow.WithinTx(ctx, func(ctx context.Context) error {
+		return u.ucDeleteUser.Execute(ctx, ucReqModel)
+	})
+	u.observe(span, "delete_user", err)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %+v", err))
+		return respondError(ctx, err)
+	}
+
+	res.StatusCode = http.StatusNoContent // 204
+
+	u.logger.Debug(ctx, "ending delete user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// Restore ...
+func (u user) Restore(req RestRequest) (res RestResponse) {
+	startTime := time.Now()
+	ctx := req.newContext()
+	u.logger.Debug(ctx, "starting restore user")
+
+	id, err := pathUserID(req)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when parsing user id: %v", err))
+		return respondError(ctx, businesserr.ErrRestoreUserNotFound)
+	}
+
+	ctx, span := u.trace(ctx, "restore_user")
+	var ucResModel interactor.RestoreUserResponseModel
+	err = u.uow.WithinTx(ctx, func(ctx context.Context) (err error) {
+		ucResModel, err = u.ucRestoreUser.Execute(ctx, interactor.RestoreUserRequestModel{ID: id})
+		return
+	})
+	u.observe(span, "restore_user", err)
+	if err != nil {
+		u.logger.Error(ctx, fmt.Sprintf("error when executing core: %+v", err))
+		return respondError(ctx, err)
+	}
+
+	var resBody restoreResBody
+	resBody.ID = strconv.FormatInt(ucResModel.ID, 10) // format to string because int64 can be too big to JS
+	resBody.Name = ucResModel.Name
+	resBody.Email = ucResModel.Email
+
+	res = respondJSON(http.StatusOK, resBody)
+
+	u.logger.Debug(ctx, "ending restore user method", iinfra.LogAttrs{
+		"duration": time.Since(startTime),
+	})
+
+	return
+}
+
+// pathUserID gets the user ID from the path, an invalid ID can't match any user
+func pathUserID(req RestRequest) (int64, error) {
+	return strconv.ParseInt(req.GetPathParam("id"), 10, 64)
+}
+
+// query params accepted by the search, any other one is rejected
+var searchQueryParams = map[string]bool{
+	"email":        true,
+	"name":         true,
+	"email_domain": true,
+	"ids":          true,
+	"created_from": true,
+	"created_to":   true,
+	"limit":        true,
+	"cursor":       true,
+	"sort":         true,
+}
+
+// searchFilterFromQuery gets the search filters from the query params
+func searchFilterFromQuery(req RestRequest) (filter interactor.SearchUserRequestModel, err error) {
+	for _, key := range req.GetQueryParamKeys() {
+		if !searchQueryParams[key] {
+			err = businesserr.ErrSearchUserUnknownFilter
+			return
+		}
+	}
+
+	filter.Email = req.GetQueryParam("email")
+	filter.NameContains = req.GetQueryParam("name")
+	filter.EmailDomain = req.GetQueryParam("email_domain")
+	filter.Cursor = req.GetQueryParam("cursor")
+
+	// ids are comma separated, e.g. ids=1,2,3
+	if ids := req.GetQueryParam("ids"); ids != "" {
+		for _, id := range strings.Split(ids, ",") {
+			var parsed int64
+			if parsed, err = strconv.ParseInt(strings.TrimSpace(id), 10, 64); err != nil {
+				err = businesserr.ErrSearchUserInvalidFilter
+				return
+			}
+			filter.IDs = append(filter.IDs, parsed)
+		}
+	}
+
+	if filter.CreatedFrom, err = parseQueryTime(req.GetQueryParam("created_from")); err != nil {
+		err = businesserr.ErrSearchUserInvalidFilter
+		return
+	}
+	if filter.CreatedTo, err = parseQueryTime(req.GetQueryParam("created_to")); err != nil {
+		err = businesserr.ErrSearchUserInvalidFilter
+		return
+	}
+
+	if limit := req.GetQueryParam("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil {
+			err = businesserr.ErrSearchUserInvalidLimit
+			return
+		}
+	}
+
+	// a leading "-" sorts in descending order, e.g. sort=-name
+	filter.SortField = req.GetQueryParam("sort")
+	if strings.HasPrefix(filter.SortField, "-") {
+		filter.SortField = filter.SortField[1:]
+		filter.SortDesc = true
+	}
+
+	return
+}
+
+// parseQueryTime accepts both RFC 3339 timestamps and plain dates, an empty value results in the zero time
+func parseQueryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// trace starts the span of the execution of the interactor, the unit of work included, observe ends it
+func (u user) trace(ctx context.Context, interactor string) (context.Context, iinfra.Span) {
+	return u.tracer.Start(ctx, "interactor."+interactor+".Execute", iinfra.SpanAttrs{"interactor": interactor})
+}
+
+// observe counts the execution of the interactor by its outcome and ends its span, only the errors that
+// aren't business ones are errors of the span
+func (u user) observe(span iinfra.Span, interactor string, err error) {
+	o := outcome(err)
+	u.metrics.Counter(metricInteractorExecutions, iinfra.MetricLabels{
+		"interactor": interactor,
+		"outcome":    o,
+	}, 1)
+
+	span.SetAttrs(iinfra.SpanAttrs{"outcome": o})
+	if o == codeInternal {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -5,13 +5,17 @@
 package restctrl
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
 
+	"github.com/dougefr/go-clean-arch/interface/iinfra"
 	"github.com/dougefr/go-clean-arch/interface/iinfra/mock_iinfra"
 	"github.com/dougefr/go-clean-arch/usecase/interactor"
 	"github.com/dougefr/go-clean-arch/usecase/interactor/mock_interactor"
@@ -25,22 +29,23 @@ func TestUserCreate(t *testing.T) {
 	const fakeEmail = "fake@email.com"
 	fakeError := errors.New("fake-error")
 
-	t.Run("should results in StatusInternalServerError if the request body is an invalid JSON", func(t *testing.T) {
+	t.Run("should results in StatusBadRequest if the request body is an invalid JSON", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
 		logger.EXPECT().Error(gomock.Any(), gomock.Any())
-		c := NewUser(nil, nil, nil, logger)
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		res := c.Create(RestRequest{
 			Body: []byte("I'm an invalid JSON"),
 		})
 
-		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Contains(t, string(res.Body), `"code":"ErrMalformedBody"`)
 	})
 
-	t.Run("should results in StatusInternalServerError if open a new Tx on database results in an error", func(t *testing.T) {
+	t.Run("should results in StatusInternalServerError if the tx fails before the usecase interactor is executed", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -48,10 +53,10 @@ func TestUserCreate(t *testing.T) {
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
 		logger.EXPECT().Error(gomock.Any(), gomock.Any())
 
-		session := mock_iinfra.NewMockSession(ctrl)
-		session.EXPECT().BeginTx().Return(nil, fakeError)
+		uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+		uow.EXPECT().WithinTx(gomock.Any(), gomock.Any()).Return(fakeError)
 
-		c := NewUser(nil, nil, session, logger)
+		c := NewUser(nil, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		res := c.Create(RestRequest{
 			Body: []byte(fakeJSON),
 		})
@@ -59,7 +64,7 @@ func TestUserCreate(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 	})
 
-	t.Run("should results in StatusBadRequest if usecase interactor return any business error", func(t *testing.T) {
+	t.Run("should results in StatusConflict if usecase interactor return a conflict business error", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -67,19 +72,17 @@ func TestUserCreate(t *testing.T) {
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
 		logger.EXPECT().Error(gomock.Any(), gomock.Any())
 
-		session := mock_iinfra.NewMockSession(ctrl)
-		session.EXPECT().BeginTx().Return(mock_iinfra.NewMockTx(ctrl), nil)
-		session.EXPECT().RollbackTx(gomock.Any()).Return(nil)
+		uow := runUnitOfWork(ctrl, nil)
 
 		ucCreateUser := mock_interactor.NewMockCreateUser(ctrl)
-		ucCreateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.CreateUserResponseModel{}, businesserr.ErrCreateUserErrEmptyEmail)
+		ucCreateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.CreateUserResponseModel{}, businesserr.ErrCreateUserAlreadyExists)
 
-		c := NewUser(ucCreateUser, nil, session, logger)
+		c := NewUser(ucCreateUser, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		res := c.Create(RestRequest{
 			Body: []byte(fakeJSON),
 		})
 
-		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
 	})
 
 	t.Run("should results in StatusInternalServerError if usecase interactor return any unknown error", func(t *testing.T) {
@@ -90,14 +93,12 @@ func TestUserCreate(t *testing.T) {
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
 		logger.EXPECT().Error(gomock.Any(), gomock.Any())
 
-		session := mock_iinfra.NewMockSession(ctrl)
-		session.EXPECT().BeginTx().Return(mock_iinfra.NewMockTx(ctrl), nil)
-		session.EXPECT().RollbackTx(gomock.Any()).Return(nil)
+		uow := runUnitOfWork(ctrl, nil)
 
 		ucCreateUser := mock_interactor.NewMockCreateUser(ctrl)
 		ucCreateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.CreateUserResponseModel{}, fakeError)
 
-		c := NewUser(ucCreateUser, nil, session, logger)
+		c := NewUser(ucCreateUser, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		res := c.Create(RestRequest{
 			Body: []byte(fakeJSON),
 		})
@@ -105,7 +106,7 @@ func TestUserCreate(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 	})
 
-	t.Run("should results in StatusInternalServerError when commiting Tx results in an error", func(t *testing.T) {
+	t.Run("should results in StatusInternalServerError when the tx can't be committed", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
@@ -113,14 +114,12 @@ func TestUserCreate(t *testing.T) {
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
 		logger.EXPECT().Error(gomock.Any(), gomock.Any())
 
-		session := mock_iinfra.NewMockSession(ctrl)
-		session.EXPECT().BeginTx().Return(mock_iinfra.NewMockTx(ctrl), nil)
-		session.EXPECT().CommitTx(gomock.Any()).Return(fakeError)
+		uow := runUnitOfWork(ctrl, fakeError)
 
 		ucCreateUser := mock_interactor.NewMockCreateUser(ctrl)
 		ucCreateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.CreateUserResponseModel{}, nil)
 
-		c := NewUser(ucCreateUser, nil, session, logger)
+		c := NewUser(ucCreateUser, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		res := c.Create(RestRequest{
 			Body: []byte(fakeJSON),
 		})
@@ -135,9 +134,7 @@ func TestUserCreate(t *testing.T) {
 		logger := mock_iinfra.NewMockLogProvider(ctrl)
 		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
 
-		session := mock_iinfra.NewMockSession(ctrl)
-		session.EXPECT().BeginTx().Return(mock_iinfra.NewMockTx(ctrl), nil)
-		session.EXPECT().CommitTx(gomock.Any()).Return(nil)
+		uow := runUnitOfWork(ctrl, nil)
 
 		ucCreateUser := mock_interactor.NewMockCreateUser(ctrl)
 		ucCreateUser.EXPECT().Execute(gomock.Any(), interactor.CreateUserRequestModel{
@@ -150,7 +147,7 @@ func TestUserCreate(t *testing.T) {
 				Email: fakeEmail,
 			}, nil)
 
-		c := NewUser(ucCreateUser, nil, session, logger)
+		c := NewUser(ucCreateUser, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
 		res := c.Create(RestRequest{
 			Body: []byte(fakeJSON),
 		})
@@ -160,6 +157,7 @@ func TestUserCreate(t *testing.T) {
 
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		assert.Equal(t, "application/json", res.ContentType)
 		assert.Equal(t, createResBody{
 			ID:    "1",
 			Name:  fakeName,
@@ -184,16 +182,156 @@ func TestUserSearch(t *testing.T) {
 		ucSearchUser := mock_interactor.NewMockSearchUser(ctrl)
 		ucSearchUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.SearchUserResponseModel{}, fakeError)
 
-		c := NewUser(nil, ucSearchUser, nil, logger)
-		res := c.Search(RestRequest{
-			GetQueryParam: func(key string) string {
-				return fakeEmail
-			},
-		})
+		c := NewUser(nil, ucSearchUser, nil, nil, nil, nil, searchUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{"email": fakeEmail}))
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should results in StatusInternalServerError if the tx can't be started", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+		uow.EXPECT().WithinTxOptions(gomock.Any(), gomock.Any(), gomock.Any()).Return(fakeError)
+
+		c := NewUser(nil, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{"email": fakeEmail}))
 
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 	})
 
+	t.Run("should results in StatusBadRequest if the limit is not a number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{"limit": "ten"}))
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("should results in StatusBadRequest if there is an unknown query param", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{"password": "123"}))
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	t.Run("should results in StatusBadRequest if a filter is malformed", func(t *testing.T) {
+		for _, params := range []map[string]string{
+			{"ids": "1,two"},
+			{"created_from": "yesterday"},
+			{"created_to": "2020-13-01"},
+		} {
+			ctrl := gomock.NewController(t)
+
+			logger := mock_iinfra.NewMockLogProvider(ctrl)
+			logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+			logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+			c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+			res := c.Search(requestWithQuery(params))
+
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode, params)
+			ctrl.Finish()
+		}
+	})
+
+	t.Run("should send the request context to the usecase interactor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		reqCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ucSearchUser := mock_interactor.NewMockSearchUser(ctrl)
+		ucSearchUser.EXPECT().Execute(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _ interactor.SearchUserRequestModel) (interactor.SearchUserResponseModel, error) {
+				assert.Equal(t, context.Canceled, ctx.Err())
+				return interactor.SearchUserResponseModel{}, nil
+			})
+
+		req := requestWithQuery(map[string]string{})
+		req.Context = reqCtx
+		c := NewUser(nil, ucSearchUser, nil, nil, nil, nil, searchUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		c.Search(req)
+	})
+
+	t.Run("should send the filters to the usecase interactor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		ucSearchUser := mock_interactor.NewMockSearchUser(ctrl)
+		ucSearchUser.EXPECT().Execute(gomock.Any(), interactor.SearchUserRequestModel{
+			Email:        fakeEmail,
+			NameContains: "fake",
+			EmailDomain:  "email.com",
+			IDs:          []int64{1, 2, 3},
+			CreatedFrom:  time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+			CreatedTo:    time.Date(2020, 2, 1, 12, 30, 0, 0, time.UTC),
+		}).Return(interactor.SearchUserResponseModel{}, nil)
+
+		c := NewUser(nil, ucSearchUser, nil, nil, nil, nil, searchUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{
+			"email":        fakeEmail,
+			"name":         "fake",
+			"email_domain": "email.com",
+			"ids":          "1, 2,3",
+			"created_from": "2020-01-01",
+			"created_to":   "2020-02-01T12:30:00Z",
+		}))
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("should send the pagination and the sort to the usecase interactor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		ucSearchUser := mock_interactor.NewMockSearchUser(ctrl)
+		ucSearchUser.EXPECT().Execute(gomock.Any(), interactor.SearchUserRequestModel{
+			Limit:     10,
+			Cursor:    "fake-cursor",
+			SortField: "name",
+			SortDesc:  true,
+		}).Return(interactor.SearchUserResponseModel{}, nil)
+
+		c := NewUser(nil, ucSearchUser, nil, nil, nil, nil, searchUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{
+			"limit":  "10",
+			"cursor": "fake-cursor",
+			"sort":   "-name",
+		}))
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.JSONEq(t, `{"users":[],"total":0}`, string(res.Body))
+	})
+
 	t.Run("should results in StatusOK if usecase interactor when everything goes fine", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
@@ -215,31 +353,676 @@ func TestUserSearch(t *testing.T) {
 					Email: fakeEmail,
 				},
 			},
+			NextCursor: "fake-cursor",
+			Total:      3,
 		}, nil)
 
-		c := NewUser(nil, ucSearchUser, nil, logger)
-		res := c.Search(RestRequest{
-			GetQueryParam: func(key string) string {
-				return fakeEmail
+		c := NewUser(nil, ucSearchUser, nil, nil, nil, nil, searchUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Search(requestWithQuery(map[string]string{"email": fakeEmail}))
+
+		var resBody searchResBody
+		err := json.Unmarshal(res.Body, &resBody)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, searchResBody{
+			Users: []searchResBodyUser{
+				{
+					ID:    "1",
+					Name:  fakeName,
+					Email: fakeEmail,
+				},
+				{
+					ID:    "2",
+					Name:  fakeName,
+					Email: fakeEmail,
+				},
+			},
+			NextCursor: "fake-cursor",
+			Total:      3,
+		}, resBody)
+	})
+}
+
+func TestUserUpdate(t *testing.T) {
+	const fakeJSON = `{"name":"fake name","email":"fake@email.com"}`
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	fakeError := errors.New("fake-error")
+	getPathParam := func(key string) string {
+		return "1"
+	}
+
+	t.Run("should results in StatusBadRequest if the request body is an invalid JSON", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Update(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte("I'm an invalid JSON"),
+		})
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Contains(t, string(res.Body), `"code":"ErrMalformedBody"`)
+	})
+
+	t.Run("should results in StatusNotFound if the ID is not a number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Update(RestRequest{
+			GetPathParam: func(key string) string {
+				return "invalid"
 			},
+			Body: []byte(fakeJSON),
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results in StatusInternalServerError if the tx fails before the usecase interactor is executed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+		uow.EXPECT().WithinTx(gomock.Any(), gomock.Any()).Return(fakeError)
+
+		c := NewUser(nil, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Update(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte(fakeJSON),
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should results in StatusConflict if usecase interactor return a conflict business error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucUpdateUser := mock_interactor.NewMockUpdateUser(ctrl)
+		ucUpdateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.UpdateUserResponseModel{}, businesserr.ErrUpdateUserAlreadyExists)
+
+		c := NewUser(nil, nil, ucUpdateUser, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Update(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte(fakeJSON),
+		})
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("should results in StatusInternalServerError when the tx can't be committed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := runUnitOfWork(ctrl, fakeError)
+
+		ucUpdateUser := mock_interactor.NewMockUpdateUser(ctrl)
+		ucUpdateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.UpdateUserResponseModel{}, nil)
+
+		c := NewUser(nil, nil, ucUpdateUser, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Update(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte(fakeJSON),
 		})
 
-		var resBody []searchResBody
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should results in StatusOK and returns the updated user when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		name, email := fakeName, fakeEmail
+		ucUpdateUser := mock_interactor.NewMockUpdateUser(ctrl)
+		ucUpdateUser.EXPECT().Execute(gomock.Any(), interactor.UpdateUserRequestModel{
+			ID:    1,
+			Name:  &name,
+			Email: &email,
+		}).
+			Return(interactor.UpdateUserResponseModel{
+				ID:    1,
+				Name:  fakeName,
+				Email: fakeEmail,
+			}, nil)
+
+		c := NewUser(nil, nil, ucUpdateUser, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Update(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte(fakeJSON),
+		})
+
+		var resBody updateResBody
 		err := json.Unmarshal(res.Body, &resBody)
 
 		assert.NoError(t, err)
 		assert.Equal(t, http.StatusOK, res.StatusCode)
-		assert.Equal(t, []searchResBody{
-			{
-				ID:    "1",
+		assert.Equal(t, updateResBody{
+			ID:    "1",
+			Name:  fakeName,
+			Email: fakeEmail,
+		}, resBody)
+	})
+}
+
+func TestUserPatch(t *testing.T) {
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	getPathParam := func(key string) string {
+		return "1"
+	}
+
+	t.Run("should results in StatusBadRequest if the request body is an invalid JSON", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Patch(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte("I'm an invalid JSON"),
+		})
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Contains(t, string(res.Body), `"code":"ErrMalformedBody"`)
+	})
+
+	t.Run("should results in StatusNotFound if usecase interactor return ErrUpdateUserNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucUpdateUser := mock_interactor.NewMockUpdateUser(ctrl)
+		ucUpdateUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.UpdateUserResponseModel{}, businesserr.ErrUpdateUserNotFound)
+
+		c := NewUser(nil, nil, ucUpdateUser, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Patch(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte(`{"name":"fake name"}`),
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should send only the informed fields to the usecase interactor", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		name := fakeName
+		ucUpdateUser := mock_interactor.NewMockUpdateUser(ctrl)
+		ucUpdateUser.EXPECT().Execute(gomock.Any(), interactor.UpdateUserRequestModel{
+			ID:   1,
+			Name: &name,
+		}).
+			Return(interactor.UpdateUserResponseModel{
+				ID:    1,
 				Name:  fakeName,
 				Email: fakeEmail,
+			}, nil)
+
+		c := NewUser(nil, nil, ucUpdateUser, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Patch(RestRequest{
+			GetPathParam: getPathParam,
+			Body:         []byte(`{"name":"fake name"}`),
+		})
+
+		var resBody updateResBody
+		err := json.Unmarshal(res.Body, &resBody)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, updateResBody{
+			ID:    "1",
+			Name:  fakeName,
+			Email: fakeEmail,
+		}, resBody)
+	})
+}
+
+func TestUserDelete(t *testing.T) {
+	fakeError := errors.New("fake-error")
+	getPathParam := func(key string) string {
+		return "1"
+	}
+	getQueryParam := func(key string) string {
+		return ""
+	}
+
+	t.Run("should results in StatusNotFound if the ID is not a number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Delete(RestRequest{
+			GetPathParam: func(key string) string {
+				return "invalid"
 			},
-			{
-				ID:    "2",
+			GetQueryParam: getQueryParam,
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results in StatusInternalServerError if the tx fails before the usecase interactor is executed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+		uow.EXPECT().WithinTx(gomock.Any(), gomock.Any()).Return(fakeError)
+
+		c := NewUser(nil, nil, nil, nil, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Delete(RestRequest{
+			GetPathParam:  getPathParam,
+			GetQueryParam: getQueryParam,
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should results in StatusNotFound if usecase interactor return ErrDeleteUserNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucDeleteUser := mock_interactor.NewMockDeleteUser(ctrl)
+		ucDeleteUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(businesserr.ErrDeleteUserNotFound)
+
+		c := NewUser(nil, nil, nil, ucDeleteUser, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Delete(RestRequest{
+			GetPathParam:  getPathParam,
+			GetQueryParam: getQueryParam,
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results in StatusInternalServerError when the tx can't be committed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := runUnitOfWork(ctrl, fakeError)
+
+		ucDeleteUser := mock_interactor.NewMockDeleteUser(ctrl)
+		ucDeleteUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(nil)
+
+		c := NewUser(nil, nil, nil, ucDeleteUser, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Delete(RestRequest{
+			GetPathParam:  getPathParam,
+			GetQueryParam: getQueryParam,
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should results in StatusNoContent and soft delete the user when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucDeleteUser := mock_interactor.NewMockDeleteUser(ctrl)
+		ucDeleteUser.EXPECT().Execute(gomock.Any(), interactor.DeleteUserRequestModel{ID: 1}).Return(nil)
+
+		c := NewUser(nil, nil, nil, ucDeleteUser, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Delete(RestRequest{
+			GetPathParam:  getPathParam,
+			GetQueryParam: getQueryParam,
+		})
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Empty(t, res.Body)
+	})
+
+	t.Run("should ask the usecase interactor to purge the user when purge query param is true", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucDeleteUser := mock_interactor.NewMockDeleteUser(ctrl)
+		ucDeleteUser.EXPECT().Execute(gomock.Any(), interactor.DeleteUserRequestModel{ID: 1, Purge: true}).Return(nil)
+
+		c := NewUser(nil, nil, nil, ucDeleteUser, nil, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Delete(RestRequest{
+			GetPathParam: getPathParam,
+			GetQueryParam: func(key string) string {
+				return "true"
+			},
+		})
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestUserRestore(t *testing.T) {
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	getPathParam := func(key string) string {
+		return "1"
+	}
+
+	t.Run("should results in StatusNotFound if the ID is not a number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Restore(RestRequest{
+			GetPathParam: func(key string) string {
+				return "invalid"
+			},
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results in StatusConflict if usecase interactor return a conflict business error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucRestoreUser := mock_interactor.NewMockRestoreUser(ctrl)
+		ucRestoreUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.RestoreUserResponseModel{}, businesserr.ErrRestoreUserAlreadyExists)
+
+		c := NewUser(nil, nil, nil, nil, ucRestoreUser, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Restore(RestRequest{
+			GetPathParam: getPathParam,
+		})
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+	})
+
+	t.Run("should results in StatusOK and returns the restored user when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		uow := runUnitOfWork(ctrl, nil)
+
+		ucRestoreUser := mock_interactor.NewMockRestoreUser(ctrl)
+		ucRestoreUser.EXPECT().Execute(gomock.Any(), interactor.RestoreUserRequestModel{ID: 1}).
+			Return(interactor.RestoreUserResponseModel{
+				ID:    1,
 				Name:  fakeName,
 				Email: fakeEmail,
+			}, nil)
+
+		c := NewUser(nil, nil, nil, nil, ucRestoreUser, nil, uow, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Restore(RestRequest{
+			GetPathParam: getPathParam,
+		})
+
+		var resBody restoreResBody
+		err := json.Unmarshal(res.Body, &resBody)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, restoreResBody{
+			ID:    "1",
+			Name:  fakeName,
+			Email: fakeEmail,
+		}, resBody)
+	})
+}
+
+func TestUserGet(t *testing.T) {
+	fakeError := errors.New("fake-error")
+	const fakeName = "fake name"
+	const fakeEmail = "fake@email.com"
+	getPathParam := func(key string) string {
+		return "1"
+	}
+
+	t.Run("should results in StatusNotFound if the ID is not a number", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+		c := NewUser(nil, nil, nil, nil, nil, nil, nil, logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Get(RestRequest{
+			GetPathParam: func(key string) string {
+				return "invalid"
 			},
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results in StatusNotFound if usecase interactor return ErrGetUserNotFound", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		ucGetUser := mock_interactor.NewMockGetUser(ctrl)
+		ucGetUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.GetUserResponseModel{}, businesserr.ErrGetUserNotFound)
+
+		// the outcome of the interactor is its business error
+		metrics := mock_iinfra.NewMockMetricsProvider(ctrl)
+		metrics.EXPECT().Counter(metricInteractorExecutions, iinfra.MetricLabels{
+			"interactor": "get_user",
+			"outcome":    businesserr.ErrGetUserNotFound.Code(),
+		}, float64(1))
+
+		// and it isn't an error of the span
+		span := mock_iinfra.NewMockSpan(ctrl)
+		span.EXPECT().SetAttrs(iinfra.SpanAttrs{"outcome": businesserr.ErrGetUserNotFound.Code()})
+		span.EXPECT().End()
+		tracer := mock_iinfra.NewMockTracer(ctrl)
+		tracer.EXPECT().Start(gomock.Any(), "interactor.get_user.Execute", iinfra.SpanAttrs{"interactor": "get_user"}).
+			DoAndReturn(func(ctx context.Context, _ string, _ iinfra.SpanAttrs) (context.Context, iinfra.Span) {
+				return ctx, span
+			})
+
+		c := NewUser(nil, nil, nil, nil, nil, ucGetUser, getUnitOfWork(ctrl), logger, metrics, tracer)
+		res := c.Get(RestRequest{
+			GetPathParam: getPathParam,
+		})
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("should results in StatusInternalServerError if usecase interactor return any unknown error", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any())
+		logger.EXPECT().Error(gomock.Any(), gomock.Any())
+
+		ucGetUser := mock_interactor.NewMockGetUser(ctrl)
+		ucGetUser.EXPECT().Execute(gomock.Any(), gomock.Any()).Return(interactor.GetUserResponseModel{}, fakeError)
+
+		c := NewUser(nil, nil, nil, nil, nil, ucGetUser, getUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Get(RestRequest{
+			GetPathParam: getPathParam,
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("should results in StatusOK and returns the user when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		logger := mock_iinfra.NewMockLogProvider(ctrl)
+		logger.EXPECT().Debug(gomock.Any(), gomock.Any(), gomock.Any()).Times(2)
+
+		ucGetUser := mock_interactor.NewMockGetUser(ctrl)
+		ucGetUser.EXPECT().Execute(gomock.Any(), interactor.GetUserRequestModel{ID: 1}).
+			Return(interactor.GetUserResponseModel{
+				ID:    1,
+				Name:  fakeName,
+				Email: fakeEmail,
+			}, nil)
+
+		c := NewUser(nil, nil, nil, nil, nil, ucGetUser, getUnitOfWork(ctrl), logger, anyMetrics(ctrl), anyTracer(ctrl))
+		res := c.Get(RestRequest{
+			GetPathParam: getPathParam,
+		})
+
+		var resBody getResBody
+		err := json.Unmarshal(res.Body, &resBody)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, getResBody{
+			ID:    "1",
+			Name:  fakeName,
+			Email: fakeEmail,
 		}, resBody)
 	})
 }
+
+// runUnitOfWork runs the function given to WithinTx, and fails with commitErr when it succeeds
+func runUnitOfWork(ctrl *gomock.Controller, commitErr error) iinfra.UnitOfWork {
+	uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().WithinTx(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+			if err := fn(ctx); err != nil {
+				return err
+			}
+			return commitErr
+		})
+	return uow
+}
+
+// searchUnitOfWork expects the read-only tx of the search
+func searchUnitOfWork(ctrl *gomock.Controller) iinfra.UnitOfWork {
+	uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().WithinTxOptions(gomock.Any(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true},
+		gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *sql.TxOptions, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	return uow
+}
+
+// getUnitOfWork expects the read-only tx of the get
+func getUnitOfWork(ctrl *gomock.Controller) iinfra.UnitOfWork {
+	uow := mock_iinfra.NewMockUnitOfWork(ctrl)
+	uow.EXPECT().WithinTxOptions(gomock.Any(), &sql.TxOptions{ReadOnly: true}, gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ *sql.TxOptions, fn func(context.Context) error) error {
+			return fn(ctx)
+		})
+	return uow
+}
+
+// requestWithQuery fakes a request with the query params
+func requestWithQuery(params map[string]string) RestRequest {
+	return RestRequest{
+		GetQueryParam: func(key string) string {
+			return params[key]
+		},
+		GetQueryParamKeys: func() (keys []string) {
+			for key := range params {
+				keys = append(keys, key)
+			}
+			return
+		},
+	}
+}
+
+// anyTracer starts a span that accepts every call each time
+func anyTracer(ctrl *gomock.Controller) iinfra.Tracer {
+	span := mock_iinfra.NewMockSpan(ctrl)
+	span.EXPECT().SetAttrs(gomock.Any()).AnyTimes()
+	span.EXPECT().RecordError(gomock.Any()).AnyTimes()
+	span.EXPECT().End().AnyTimes()
+	span.EXPECT().TraceID().Return("fake-trace-id").AnyTimes()
+
+	tracer := mock_iinfra.NewMockTracer(ctrl)
+	tracer.EXPECT().Start(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string, _ iinfra.SpanAttrs) (context.Context, iinfra.Span) {
+			return ctx, span
+		}).AnyTimes()
+	tracer.EXPECT().Extract(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ string) context.Context {
+			return ctx
+		}).AnyTimes()
+	return tracer
+}
+
+// anyMetrics accepts every measure
+func anyMetrics(ctrl *gomock.Controller) iinfra.MetricsProvider {
+	metrics := mock_iinfra.NewMockMetricsProvider(ctrl)
+	metrics.EXPECT().Counter(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	metrics.EXPECT().Histogram(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	metrics.EXPECT().Gauge(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	return metrics
+}
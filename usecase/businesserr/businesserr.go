@@ -9,18 +9,21 @@ type (
 	BusinessError interface {
 		error
 		Code() string
+		Category() Category
 	}
 
 	businessError struct {
-		error string
-		code  string
+		error    string
+		code     string
+		category Category
 	}
 )
 
-func newBusinessError(code, error string) BusinessError {
+func newBusinessError(category Category, code, error string) BusinessError {
 	return businessError{
-		error: error,
-		code:  code,
+		error:    error,
+		code:     code,
+		category: category,
 	}
 }
 
@@ -34,14 +37,44 @@ func (b businessError) Code() string {
 	return b.code
 }
 
+// Category ...
+func (b businessError) Category() Category {
+	return b.category
+}
+
 // Business errors that use cases interactor can result
 var (
+	// ErrMalformedBody means the request body isn't a valid JSON of the expected fields
+	ErrMalformedBody = newBusinessError(CategoryMalformed, "ErrMalformedBody", "malformed request body")
 	// ErrCreateUserNotFound ...
-	ErrCreateUserNotFound = newBusinessError("ErrCreateUserNotFound", "not found")
-	// ErrCreateUserErrEmptyName ...
-	ErrCreateUserErrEmptyName = newBusinessError("ErrCreateUserErrEmptyName", "user name cannot be empty")
-	// ErrCreateUserErrEmptyEmail ...
-	ErrCreateUserErrEmptyEmail = newBusinessError("ErrCreateUserErrEmptyEmail", "user email cannot be empty")
+	ErrCreateUserNotFound = newBusinessError(CategoryNotFound, "ErrCreateUserNotFound", "not found")
 	// ErrCreateUserAlreadyExists ...
-	ErrCreateUserAlreadyExists = newBusinessError("ErrCreateUserAlreadyExists", "user already exists")
+	ErrCreateUserAlreadyExists = newBusinessError(CategoryConflict, "ErrCreateUserAlreadyExists", "user already exists")
+	// ErrSearchUserInvalidLimit ...
+	ErrSearchUserInvalidLimit = newBusinessError(CategoryMalformed, "ErrSearchUserInvalidLimit",
+		"limit must be between 1 and 100")
+	// ErrSearchUserInvalidSort ...
+	ErrSearchUserInvalidSort = newBusinessError(CategoryMalformed, "ErrSearchUserInvalidSort",
+		"users can only be sorted by id, name or email")
+	// ErrSearchUserInvalidCursor ...
+	ErrSearchUserInvalidCursor = newBusinessError(CategoryMalformed, "ErrSearchUserInvalidCursor", "invalid cursor")
+	// ErrSearchUserInvalidFilter ...
+	ErrSearchUserInvalidFilter = newBusinessError(CategoryMalformed, "ErrSearchUserInvalidFilter",
+		"invalid search filter")
+	// ErrSearchUserUnknownFilter ...
+	ErrSearchUserUnknownFilter = newBusinessError(CategoryMalformed, "ErrSearchUserUnknownFilter",
+		"unknown search filter")
+	// ErrUpdateUserNotFound ...
+	ErrUpdateUserNotFound = newBusinessError(CategoryNotFound, "ErrUpdateUserNotFound", "user not found")
+	// ErrUpdateUserAlreadyExists ...
+	ErrUpdateUserAlreadyExists = newBusinessError(CategoryConflict, "ErrUpdateUserAlreadyExists", "user already exists")
+	// ErrGetUserNotFound ...
+	ErrGetUserNotFound = newBusinessError(CategoryNotFound, "ErrGetUserNotFound", "user not found")
+	// ErrDeleteUserNotFound ...
+	ErrDeleteUserNotFound = newBusinessError(CategoryNotFound, "ErrDeleteUserNotFound", "user not found")
+	// ErrRestoreUserNotFound ...
+	ErrRestoreUserNotFound = newBusinessError(CategoryNotFound, "ErrRestoreUserNotFound", "deleted user not found")
+	// ErrRestoreUserAlreadyExists ...
+	ErrRestoreUserAlreadyExists = newBusinessError(CategoryConflict, "ErrRestoreUserAlreadyExists",
+		"another user already exists with the same email")
 )
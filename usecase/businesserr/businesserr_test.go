@@ -14,9 +14,32 @@ func TestBusinessError(t *testing.T) {
 	const fakeError = "fakeError"
 	const fakeCode = "fakeCode"
 
-	t.Run("should return the correct error string and error code", func(t *testing.T) {
-		err := newBusinessError(fakeCode, fakeError)
+	t.Run("should return the correct error string, error code and category", func(t *testing.T) {
+		err := newBusinessError(CategoryConflict, fakeCode, fakeError)
 		assert.Equal(t, fakeError, err.Error())
 		assert.Equal(t, fakeCode, err.Code())
+		assert.Equal(t, CategoryConflict, err.Category())
+	})
+}
+
+func TestValidationError(t *testing.T) {
+	t.Run("should have no error when there is no violation", func(t *testing.T) {
+		var violations Violations
+		assert.NoError(t, violations.Err())
+	})
+
+	t.Run("should have every violation in the error", func(t *testing.T) {
+		var violations Violations
+		violations.Add("name", ViolationRequired, "name cannot be empty", nil)
+		violations.Add("email", "too_long", "email is too long", map[string]interface{}{"max": 10})
+
+		err := violations.Err()
+		assert.EqualError(t, err, "invalid fields: name: name cannot be empty; email: email is too long")
+		assert.Equal(t, ValidationError{Violations: []FieldViolation{
+			{Field: "name", Code: ViolationRequired, Message: "name cannot be empty"},
+			{Field: "email", Code: "too_long", Message: "email is too long", Params: map[string]interface{}{"max": 10}},
+		}}, err)
+		assert.Equal(t, codeValidation, err.(BusinessError).Code())
+		assert.Equal(t, CategoryValidation, err.(BusinessError).Category())
 	})
 }
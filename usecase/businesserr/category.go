@@ -0,0 +1,39 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package businesserr
+
+// Category groups the business errors by what the client can do about them, each transport maps the
+// categories to its own status codes, ex: the HTTP ones
+type Category string
+
+// Categories of the business errors
+const (
+	// CategoryMalformed means the request can't be read, ex: a query param that isn't a number, it must be
+	// fixed before being retried
+	CategoryMalformed Category = "malformed"
+	// CategoryValidation means the request has invalid values, it must be fixed before being retried
+	CategoryValidation Category = "validation"
+	// CategoryNotFound ...
+	CategoryNotFound Category = "not-found"
+	// CategoryConflict means the request conflicts with the current state, ex: an email already in use
+	CategoryConflict Category = "conflict"
+	// CategoryForbidden ...
+	CategoryForbidden Category = "forbidden"
+	// CategoryPreconditionFailed means the state has changed since the client has read it
+	CategoryPreconditionFailed Category = "precondition-failed"
+	// CategoryRateLimited means the request can be retried later
+	CategoryRateLimited Category = "rate-limited"
+)
+
+// Categories lists every category, so the transports can check that they map all of them
+var Categories = []Category{
+	CategoryMalformed,
+	CategoryValidation,
+	CategoryNotFound,
+	CategoryConflict,
+	CategoryForbidden,
+	CategoryPreconditionFailed,
+	CategoryRateLimited,
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package businesserr
+
+import "strings"
+
+// codeValidation is the code of every ValidationError
+const codeValidation = "ErrValidation"
+
+// Codes of the field violations, clients can rely on them to build their own messages
+const (
+	// ViolationRequired ...
+	ViolationRequired = "required"
+	// ViolationInvalidFormat ...
+	ViolationInvalidFormat = "invalid_format"
+	// ViolationTooShort has the min length as param
+	ViolationTooShort = "too_short"
+	// ViolationTooLong has the max length as param
+	ViolationTooLong = "too_long"
+	// ViolationForbiddenCharacter has the first forbidden char as param, ex: U+202E
+	ViolationForbiddenCharacter = "forbidden_character"
+)
+
+type (
+	// FieldViolation is a field of the request that breaks a rule. Params has the values of the rule,
+	// ex: the max length, the ones without values have no params
+	FieldViolation struct {
+		Field   string
+		Code    string
+		Message string
+		Params  map[string]interface{}
+	}
+
+	// ValidationError has every field violation found in a request, so they can be fixed at once
+	ValidationError struct {
+		Violations []FieldViolation
+	}
+
+	// Violations collects the field violations of a request
+	Violations []FieldViolation
+)
+
+// Error ...
+func (v ValidationError) Error() string {
+	messages := make([]string, 0, len(v.Violations))
+	for _, violation := range v.Violations {
+		messages = append(messages, violation.Field+": "+violation.Message)
+	}
+	return "invalid fields: " + strings.Join(messages, "; ")
+}
+
+// Code ...
+func (v ValidationError) Code() string {
+	return codeValidation
+}
+
+// Category ...
+func (v ValidationError) Category() Category {
+	return CategoryValidation
+}
+
+// Add ...
+func (v *Violations) Add(field, code, message string, params map[string]interface{}) {
+	*v = append(*v, FieldViolation{
+		Field:   field,
+		Code:    code,
+		Message: message,
+		Params:  params,
+	})
+}
+
+// Err is a ValidationError with the violations, or nil when there is none
+func (v Violations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return ValidationError{Violations: v}
+}
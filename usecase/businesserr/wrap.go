@@ -0,0 +1,108 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package businesserr
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// max frames of the call stack kept by Wrap
+const maxStackDepth = 32
+
+// wrappedError carries the cause, the details and the call stack of a business error. It isn't a
+// BusinessError itself, errors.Is and errors.As find the wrapped one, so its identity is kept
+type wrappedError struct {
+	err     BusinessError
+	cause   error
+	details map[string]interface{}
+	stack   []uintptr
+}
+
+// Wrap attaches the cause and the details to the business error, both can be nil. The error can be
+// wrapped again, ex: with fmt.Errorf and %w, errors.Is and errors.As still find the business error
+func Wrap(err BusinessError, cause error, details map[string]interface{}) error {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(2, pcs[:])
+
+	return &wrappedError{
+		err:     err,
+		cause:   cause,
+		details: details,
+		stack:   pcs[:n],
+	}
+}
+
+// Details merges the details attached by Wrap along the chain of the error, the outer ones win
+func Details(err error) map[string]interface{} {
+	var details map[string]interface{}
+	for ; err != nil; err = errors.Unwrap(err) {
+		wrapped, ok := err.(*wrappedError)
+		if !ok {
+			continue
+		}
+
+		for key, value := range wrapped.details {
+			if details == nil {
+				details = make(map[string]interface{})
+			}
+			if _, ok := details[key]; !ok {
+				details[key] = value
+			}
+		}
+	}
+
+	return details
+}
+
+// Error ...
+func (w *wrappedError) Error() string {
+	if w.cause == nil {
+		return w.err.Error()
+	}
+	return w.err.Error() + ": " + w.cause.Error()
+}
+
+// Unwrap returns the cause, the business error is found by Is and As
+func (w *wrappedError) Unwrap() error {
+	return w.cause
+}
+
+// Is ...
+func (w *wrappedError) Is(target error) bool {
+	return errors.Is(w.err, target)
+}
+
+// As ...
+func (w *wrappedError) As(target interface{}) bool {
+	return errors.As(w.err, target)
+}
+
+// Format prints the details and the call stack of Wrap with %+v
+func (w *wrappedError) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		_, _ = io.WriteString(s, w.Error())
+		return
+	}
+
+	_, _ = io.WriteString(s, w.err.Error())
+	if w.cause != nil {
+		_, _ = fmt.Fprintf(s, ": %+v", w.cause)
+	}
+	if len(w.details) > 0 {
+		_, _ = fmt.Fprintf(s, "\ndetails: %v", w.details)
+	}
+
+	frames := runtime.CallersFrames(w.stack)
+	for {
+		frame, more := frames.Next()
+		_, _ = fmt.Fprintf(s, "\n%s\n\t%s:%d", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+}
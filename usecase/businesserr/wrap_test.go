@@ -0,0 +1,60 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package businesserr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap(t *testing.T) {
+	cause := errors.New("fake cause")
+
+	t.Run("should keep the identity of the business error through any wrapping", func(t *testing.T) {
+		err := fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", Wrap(ErrUpdateUserAlreadyExists, cause, nil)))
+
+		assert.True(t, errors.Is(err, ErrUpdateUserAlreadyExists))
+		assert.True(t, errors.Is(err, cause))
+		assert.False(t, errors.Is(err, ErrCreateUserAlreadyExists))
+
+		var be BusinessError
+		assert.True(t, errors.As(err, &be))
+		assert.Equal(t, ErrUpdateUserAlreadyExists, be)
+	})
+
+	t.Run("should find a wrapped validation error", func(t *testing.T) {
+		var violations Violations
+		violations.Add("name", ViolationRequired, "name cannot be empty", nil)
+		err := fmt.Errorf("validate: %w", Wrap(violations.Err().(BusinessError), nil, nil))
+
+		var ve ValidationError
+		assert.True(t, errors.As(err, &ve))
+		assert.Len(t, ve.Violations, 1)
+	})
+
+	t.Run("should tell the cause in the message", func(t *testing.T) {
+		assert.EqualError(t, Wrap(ErrGetUserNotFound, cause, nil), "user not found: fake cause")
+		assert.EqualError(t, Wrap(ErrGetUserNotFound, nil, nil), "user not found")
+	})
+
+	t.Run("should print the details and the call stack with %+v", func(t *testing.T) {
+		err := Wrap(ErrGetUserNotFound, cause, map[string]interface{}{"id": 1})
+
+		assert.Equal(t, "user not found: fake cause", fmt.Sprintf("%v", err))
+		assert.Contains(t, fmt.Sprintf("%+v", err), "user not found: fake cause\ndetails: map[id:1]\n")
+		assert.Contains(t, fmt.Sprintf("%+v", err), "businesserr.TestWrap")
+	})
+
+	t.Run("should merge the details of the chain, the outer ones win", func(t *testing.T) {
+		inner := Wrap(ErrGetUserNotFound, nil, map[string]interface{}{"id": 1, "by": "inner"})
+		outer := Wrap(ErrGetUserNotFound, fmt.Errorf("find: %w", inner), map[string]interface{}{"by": "outer"})
+
+		assert.Equal(t, map[string]interface{}{"id": 1, "by": "outer"}, Details(outer))
+		assert.Nil(t, Details(ErrGetUserNotFound))
+	})
+}
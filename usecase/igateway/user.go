@@ -6,13 +6,57 @@ package igateway
 
 import (
 	"context"
+	"time"
 
 	"github.com/dougefr/go-clean-arch/entity"
 )
 
-// User ...
-type User interface {
-	FindByEmail(ctx context.Context, email string) (entity.User, error)
-	FindAll(ctx context.Context) ([]entity.User, error)
-	Create(ctx context.Context, user entity.User) (entity.User, error)
-}
+// Fields that users can be sorted by
+const (
+	UserSortByID    UserSortField = "id"
+	UserSortByName  UserSortField = "name"
+	UserSortByEmail UserSortField = "email"
+)
+
+type (
+	// User ...
+	User interface {
+		FindByID(ctx context.Context, id int64) (entity.User, error)
+		FindDeletedByID(ctx context.Context, id int64) (entity.User, error)
+		FindByEmail(ctx context.Context, email string) (entity.User, error)
+		FindAll(ctx context.Context, filter UserFilter, page UserPage) ([]entity.User, error)
+		Count(ctx context.Context, filter UserFilter) (int64, error)
+		Create(ctx context.Context, user entity.User) (entity.User, error)
+		Update(ctx context.Context, user entity.User) (entity.User, error)
+		Delete(ctx context.Context, id int64) error
+		Purge(ctx context.Context, id int64) error
+		Restore(ctx context.Context, id int64) error
+	}
+
+	// UserFilter holds the conditions that users must match, the empty ones are ignored
+	UserFilter struct {
+		Email        string  // exact match
+		NameContains string  // case-insensitive substring of the name
+		EmailDomain  string  // case-insensitive domain of the email, without the @
+		IDs          []int64 // any of the IDs
+		CreatedFrom  time.Time
+		CreatedTo    time.Time // exclusive
+	}
+
+	// UserSortField ...
+	UserSortField string
+
+	// UserPage ...
+	UserPage struct {
+		Limit     int         // zero means no limit
+		After     *UserCursor // nil means the first page
+		SortField UserSortField
+		SortDesc  bool
+	}
+
+	// UserCursor points to the last user of the previous page, ties on the sort field are broken by the ID
+	UserCursor struct {
+		ID    int64
+		Value string // value of the sort field, unused when sorting by ID
+	}
+)
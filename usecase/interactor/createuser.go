@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/dougefr/go-clean-arch/entity"
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
@@ -35,13 +36,17 @@ type (
 
 	createUser struct {
 		userGateway igateway.User
+		nameRules   NameRules
+		emailRules  entity.EmailRules
 	}
 )
 
 // NewCreateUser ...
-func NewCreateUser(userGateway igateway.User) CreateUser {
+func NewCreateUser(userGateway igateway.User, nameRules NameRules, emailRules entity.EmailRules) CreateUser {
 	return createUser{
 		userGateway: userGateway,
+		nameRules:   nameRules,
+		emailRules:  emailRules,
 	}
 }
 
@@ -49,17 +54,13 @@ func NewCreateUser(userGateway igateway.User) CreateUser {
 func (c createUser) Execute(ctx context.Context,
 	user CreateUserRequestModel) (response CreateUserResponseModel, err error) {
 	// Static validations
-	if user.Name == "" {
-		err = businesserr.ErrCreateUserErrEmptyName
-		return
-	}
-	if user.Email == "" {
-		err = businesserr.ErrCreateUserErrEmptyEmail
+	name, canonicalEmail, err := validateUser(c.nameRules, c.emailRules, &user.Name, &user.Email)
+	if err != nil {
 		return
 	}
 
-	// Check if an user exists with the same email
-	if _, err = c.userGateway.FindByEmail(ctx, user.Email); err != nil &&
+	// Check if an user exists with the same email, in any of its forms
+	if _, err = c.userGateway.FindByEmail(ctx, canonicalEmail); err != nil &&
 		!errors.Is(err, businesserr.ErrCreateUserNotFound) {
 		err = fmt.Errorf("find by email: %w", err)
 		return
@@ -71,9 +72,14 @@ func (c createUser) Execute(ctx context.Context,
 
 	// Create the user
 	userCreated, err := c.userGateway.Create(ctx, entity.User{
-		Name:  user.Name,
-		Email: user.Email,
+		Name:         name,
+		Email:        canonicalEmail,
+		DisplayEmail: strings.TrimSpace(user.Email),
 	})
+	if errors.Is(err, businesserr.ErrCreateUserAlreadyExists) {
+		// the email was taken by a concurrent request after the check above
+		return
+	}
 	if err != nil {
 		err = fmt.Errorf("create user: %w", err)
 		return
@@ -81,7 +87,7 @@ func (c createUser) Execute(ctx context.Context,
 
 	response.ID = userCreated.ID
 	response.Name = userCreated.Name
-	response.Email = userCreated.Email
+	response.Email = userCreated.DisplayEmail
 
 	return
 }
@@ -20,32 +20,67 @@ func TestCreateUserExecute(t *testing.T) {
 	const fakeEmail = "fake@email.com"
 	const fakeName = "fake name"
 
-	t.Run("should return an error ErrCreateUserErrEmptyName when user name is empty", func(t *testing.T) {
+	t.Run("should return a validation error when user name is empty", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		userGateway := mock_igateway.NewMockUser(ctrl)
 
-		uc := NewCreateUser(userGateway)
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
 		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
 			Email: fakeEmail,
 		})
 
-		assert.EqualError(t, err, businesserr.ErrCreateUserErrEmptyName.Error())
+		assert.Equal(t, businesserr.ValidationError{Violations: []businesserr.FieldViolation{
+			{Field: "name", Code: businesserr.ViolationRequired, Message: "user name cannot be empty"},
+		}}, err)
 	})
 
-	t.Run("should return an error ErrCreateUserErrEmptyEmail when user email is empty", func(t *testing.T) {
+	t.Run("should return a validation error when user email is empty", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		userGateway := mock_igateway.NewMockUser(ctrl)
 
-		uc := NewCreateUser(userGateway)
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
 		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
 			Name: fakeName,
 		})
 
-		assert.EqualError(t, err, businesserr.ErrCreateUserErrEmptyEmail.Error())
+		assert.Equal(t, businesserr.ValidationError{Violations: []businesserr.FieldViolation{
+			{Field: "email", Code: businesserr.ViolationRequired, Message: "user email cannot be empty"},
+		}}, err)
+	})
+
+	t.Run("should return a validation error when user email is not a valid address", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
+			Name:  fakeName,
+			Email: "fake..email@email.com",
+		})
+
+		assert.Equal(t, businesserr.ValidationError{Violations: []businesserr.FieldViolation{
+			{Field: "email", Code: businesserr.ViolationInvalidFormat, Message: "user email is not a valid address"},
+		}}, err)
+	})
+
+	t.Run("should return every field violation at once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), CreateUserRequestModel{})
+
+		var validationErr businesserr.ValidationError
+		assert.True(t, errors.As(err, &validationErr))
+		assert.Len(t, validationErr.Violations, 2)
 	})
 
 	t.Run("should return an unknown error when occur an error when checking if there is no user if the same email", func(t *testing.T) {
@@ -56,7 +91,7 @@ func TestCreateUserExecute(t *testing.T) {
 		expectedErr := errors.New("fake-error")
 		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, expectedErr)
 
-		uc := NewCreateUser(userGateway)
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
 		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
 			Name:  fakeName,
 			Email: fakeEmail,
@@ -72,7 +107,7 @@ func TestCreateUserExecute(t *testing.T) {
 		userGateway := mock_igateway.NewMockUser(ctrl)
 		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, nil)
 
-		uc := NewCreateUser(userGateway)
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
 		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
 			Name:  fakeName,
 			Email: fakeEmail,
@@ -89,11 +124,12 @@ func TestCreateUserExecute(t *testing.T) {
 		expectedErr := errors.New("fake-error")
 		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
 		userGateway.EXPECT().Create(context.Background(), entity.User{
-			Name:  fakeName,
-			Email: fakeEmail,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeEmail,
 		}).Return(entity.User{}, expectedErr)
 
-		uc := NewCreateUser(userGateway)
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
 		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
 			Name:  fakeName,
 			Email: fakeEmail,
@@ -102,31 +138,77 @@ func TestCreateUserExecute(t *testing.T) {
 		assert.True(t, errors.Is(err, expectedErr))
 	})
 
-	t.Run("should return the user created data when everything goes fine", func(t *testing.T) {
+	t.Run("should return an error ErrCreateUserAlreadyExists when the email was taken while creating the user", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		userGateway := mock_igateway.NewMockUser(ctrl)
 		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
 		userGateway.EXPECT().Create(context.Background(), entity.User{
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeEmail,
+		}).Return(entity.User{}, businesserr.ErrCreateUserAlreadyExists)
+
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
 			Name:  fakeName,
 			Email: fakeEmail,
+		})
+
+		assert.EqualError(t, err, businesserr.ErrCreateUserAlreadyExists.Error())
+	})
+
+	t.Run("should store the normalized fields and show the informed email when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		const displayEmail = "Fake@Email.COM"
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Create(context.Background(), entity.User{
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: displayEmail,
 		}).Return(entity.User{
-			ID:    1,
-			Name:  fakeName,
-			Email: fakeEmail,
+			ID:           1,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: displayEmail,
 		}, nil)
 
-		uc := NewCreateUser(userGateway)
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{})
 		responseModel, _ := uc.Execute(context.Background(), CreateUserRequestModel{
-			Name:  fakeName,
-			Email: fakeEmail,
+			Name:  " " + fakeName + "\n",
+			Email: " " + displayEmail + " ",
 		})
 
 		assert.Equal(t, CreateUserResponseModel{
 			ID:    1,
 			Name:  fakeName,
-			Email: fakeEmail,
+			Email: displayEmail,
 		}, responseModel)
 	})
+
+	t.Run("should keep the case of the local part when the email rules ask for it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		const displayEmail = "Fake@Email.COM"
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByEmail(context.Background(), "Fake@email.com").Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Create(context.Background(), entity.User{
+			Name:         fakeName,
+			Email:        "Fake@email.com",
+			DisplayEmail: displayEmail,
+		}).Return(entity.User{ID: 1, Name: fakeName, Email: "Fake@email.com", DisplayEmail: displayEmail}, nil)
+
+		uc := NewCreateUser(userGateway, DefaultNameRules, entity.EmailRules{CaseSensitiveLocalPart: true})
+		_, err := uc.Execute(context.Background(), CreateUserRequestModel{
+			Name:  fakeName,
+			Email: displayEmail,
+		})
+
+		assert.NoError(t, err)
+	})
 }
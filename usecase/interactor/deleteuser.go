@@ -0,0 +1,56 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+)
+
+type (
+	// DeleteUserRequestModel ...
+	DeleteUserRequestModel struct {
+		ID    int64
+		Purge bool // erase the user data permanently instead of marking it as deleted
+	}
+
+	// DeleteUser ...
+	DeleteUser interface {
+		Execute(ctx context.Context, user DeleteUserRequestModel) error
+	}
+
+	deleteUser struct {
+		userGateway igateway.User
+	}
+)
+
+// NewDeleteUser ...
+func NewDeleteUser(userGateway igateway.User) DeleteUser {
+	return deleteUser{
+		userGateway: userGateway,
+	}
+}
+
+// Execute ...
+func (c deleteUser) Execute(ctx context.Context, user DeleteUserRequestModel) (err error) {
+	if user.Purge {
+		err = c.userGateway.Purge(ctx, user.ID)
+	} else {
+		err = c.userGateway.Delete(ctx, user.ID)
+	}
+
+	if errors.Is(err, businesserr.ErrCreateUserNotFound) {
+		return businesserr.ErrDeleteUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("delete user: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway/mock_igateway"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteUserExecute(t *testing.T) {
+	const fakeID = int64(1)
+
+	t.Run("should return an error ErrDeleteUserNotFound when there is no user with the ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().Delete(context.Background(), fakeID).Return(businesserr.ErrCreateUserNotFound)
+
+		uc := NewDeleteUser(userGateway)
+		err := uc.Execute(context.Background(), DeleteUserRequestModel{ID: fakeID})
+
+		assert.EqualError(t, err, businesserr.ErrDeleteUserNotFound.Error())
+	})
+
+	t.Run("should return an unknown error when occur an error when deleting the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().Delete(context.Background(), fakeID).Return(expectedErr)
+
+		uc := NewDeleteUser(userGateway)
+		err := uc.Execute(context.Background(), DeleteUserRequestModel{ID: fakeID})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should mark the user as deleted when purge was not asked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().Delete(context.Background(), fakeID).Return(nil)
+
+		uc := NewDeleteUser(userGateway)
+		err := uc.Execute(context.Background(), DeleteUserRequestModel{ID: fakeID})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error ErrDeleteUserNotFound when there is no user to purge with the ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().Purge(context.Background(), fakeID).Return(businesserr.ErrCreateUserNotFound)
+
+		uc := NewDeleteUser(userGateway)
+		err := uc.Execute(context.Background(), DeleteUserRequestModel{ID: fakeID, Purge: true})
+
+		assert.EqualError(t, err, businesserr.ErrDeleteUserNotFound.Error())
+	})
+
+	t.Run("should purge the user when it was asked", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().Purge(context.Background(), fakeID).Return(nil)
+
+		uc := NewDeleteUser(userGateway)
+		err := uc.Execute(context.Background(), DeleteUserRequestModel{ID: fakeID, Purge: true})
+
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+)
+
+type (
+	// GetUserRequestModel ...
+	GetUserRequestModel struct {
+		ID int64
+	}
+
+	// GetUserResponseModel ...
+	GetUserResponseModel struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	// GetUser ...
+	GetUser interface {
+		Execute(ctx context.Context, user GetUserRequestModel) (GetUserResponseModel, error)
+	}
+
+	getUser struct {
+		userGateway igateway.User
+	}
+)
+
+// NewGetUser ...
+func NewGetUser(userGateway igateway.User) GetUser {
+	return getUser{
+		userGateway: userGateway,
+	}
+}
+
+// Execute ...
+func (c getUser) Execute(ctx context.Context, user GetUserRequestModel) (response GetUserResponseModel, err error) {
+	found, err := c.userGateway.FindByID(ctx, user.ID)
+	if errors.Is(err, businesserr.ErrCreateUserNotFound) {
+		err = businesserr.ErrGetUserNotFound
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("find by id: %w", err)
+		return
+	}
+
+	response.ID = found.ID
+	response.Name = found.Name
+	response.Email = found.DisplayEmail
+
+	return
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway/mock_igateway"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetUserExecute(t *testing.T) {
+	const fakeID = int64(1)
+	const fakeEmail = "fake@email.com"
+	const fakeName = "fake name"
+
+	t.Run("should return an error ErrGetUserNotFound when there is no user with the ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+
+		uc := NewGetUser(userGateway)
+		_, err := uc.Execute(context.Background(), GetUserRequestModel{ID: fakeID})
+
+		assert.EqualError(t, err, businesserr.ErrGetUserNotFound.Error())
+	})
+
+	t.Run("should return an unknown error when occur an error when finding the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(entity.User{}, expectedErr)
+
+		uc := NewGetUser(userGateway)
+		_, err := uc.Execute(context.Background(), GetUserRequestModel{ID: fakeID})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return the user data when an user exists with the ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(entity.User{
+			ID:           fakeID,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeEmail,
+		}, nil)
+
+		uc := NewGetUser(userGateway)
+		responseModel, _ := uc.Execute(context.Background(), GetUserRequestModel{ID: fakeID})
+
+		assert.Equal(t, GetUserResponseModel{
+			ID:    fakeID,
+			Name:  fakeName,
+			Email: fakeEmail,
+		}, responseModel)
+	})
+}
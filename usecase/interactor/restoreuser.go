@@ -0,0 +1,87 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+)
+
+type (
+	// RestoreUserRequestModel ...
+	RestoreUserRequestModel struct {
+		ID int64
+	}
+
+	// RestoreUserResponseModel ...
+	RestoreUserResponseModel struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	// RestoreUser ...
+	RestoreUser interface {
+		Execute(ctx context.Context, user RestoreUserRequestModel) (RestoreUserResponseModel, error)
+	}
+
+	restoreUser struct {
+		userGateway igateway.User
+	}
+)
+
+// NewRestoreUser ...
+func NewRestoreUser(userGateway igateway.User) RestoreUser {
+	return restoreUser{
+		userGateway: userGateway,
+	}
+}
+
+// Execute ...
+func (c restoreUser) Execute(ctx context.Context,
+	user RestoreUserRequestModel) (response RestoreUserResponseModel, err error) {
+	// Only deleted users can be restored, purged ones are gone forever
+	deleted, err := c.userGateway.FindDeletedByID(ctx, user.ID)
+	if errors.Is(err, businesserr.ErrCreateUserNotFound) {
+		err = businesserr.ErrRestoreUserNotFound
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("find deleted by id: %w", err)
+		return
+	}
+
+	// The email could have been taken by another user while this one was deleted
+	if _, err = c.userGateway.FindByEmail(ctx, deleted.Email); err != nil &&
+		!errors.Is(err, businesserr.ErrCreateUserNotFound) {
+		err = fmt.Errorf("find by email: %w", err)
+		return
+	}
+	if err == nil {
+		err = businesserr.ErrRestoreUserAlreadyExists
+		return
+	}
+
+	err = c.userGateway.Restore(ctx, user.ID)
+	if errors.Is(err, businesserr.ErrCreateUserAlreadyExists) {
+		// the email was taken by a concurrent request after the check above
+		err = businesserr.Wrap(businesserr.ErrRestoreUserAlreadyExists, err, nil)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("restore user: %w", err)
+		return
+	}
+
+	response.ID = deleted.ID
+	response.Name = deleted.Name
+	response.Email = deleted.DisplayEmail
+
+	return
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway/mock_igateway"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreUserExecute(t *testing.T) {
+	const fakeID = int64(1)
+	const fakeEmail = "fake@email.com"
+	const fakeName = "fake name"
+	deletedUser := entity.User{
+		ID:           fakeID,
+		Name:         fakeName,
+		Email:        fakeEmail,
+		DisplayEmail: fakeEmail,
+	}
+
+	t.Run("should return an error ErrRestoreUserNotFound when there is no deleted user with the ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+
+		uc := NewRestoreUser(userGateway)
+		_, err := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.EqualError(t, err, businesserr.ErrRestoreUserNotFound.Error())
+	})
+
+	t.Run("should return an unknown error when occur an error when finding the deleted user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(entity.User{}, expectedErr)
+
+		uc := NewRestoreUser(userGateway)
+		_, err := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return an unknown error when occur an error when checking if there is no user with the same email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(deletedUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, expectedErr)
+
+		uc := NewRestoreUser(userGateway)
+		_, err := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return an error ErrRestoreUserAlreadyExists when another user took the email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(deletedUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{ID: 2}, nil)
+
+		uc := NewRestoreUser(userGateway)
+		_, err := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.EqualError(t, err, businesserr.ErrRestoreUserAlreadyExists.Error())
+	})
+
+	t.Run("should return an error ErrRestoreUserAlreadyExists when the email was taken while restoring the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(deletedUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Restore(context.Background(), fakeID).Return(businesserr.ErrCreateUserAlreadyExists)
+
+		uc := NewRestoreUser(userGateway)
+		_, err := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.True(t, errors.Is(err, businesserr.ErrRestoreUserAlreadyExists))
+		assert.True(t, errors.Is(err, businesserr.ErrCreateUserAlreadyExists))
+	})
+
+	t.Run("should return an unknown error when occur an error when restoring the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(deletedUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Restore(context.Background(), fakeID).Return(expectedErr)
+
+		uc := NewRestoreUser(userGateway)
+		_, err := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return the restored user data when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindDeletedByID(context.Background(), fakeID).Return(deletedUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Restore(context.Background(), fakeID).Return(nil)
+
+		uc := NewRestoreUser(userGateway)
+		responseModel, _ := uc.Execute(context.Background(), RestoreUserRequestModel{ID: fakeID})
+
+		assert.Equal(t, RestoreUserResponseModel{
+			ID:    fakeID,
+			Name:  fakeName,
+			Email: fakeEmail,
+		}, responseModel)
+	})
+}
@@ -6,23 +6,44 @@ package interactor
 
 import (
 	"context"
-	"errors"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/dougefr/go-clean-arch/entity"
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
 	"github.com/dougefr/go-clean-arch/usecase/igateway"
 )
 
+// Limits of the search
+const (
+	SearchUserDefaultLimit = 20
+	SearchUserMaxLimit     = 100
+	SearchUserMaxIDs       = 100
+)
+
 type (
 	// SearchUserRequestModel ...
 	SearchUserRequestModel struct {
-		Email string
+		Email        string    // exact match
+		NameContains string    // case-insensitive substring of the name
+		EmailDomain  string    // e.g. acme.com
+		IDs          []int64   // any of the IDs
+		CreatedFrom  time.Time // zero means unbounded
+		CreatedTo    time.Time // exclusive, zero means unbounded
+		Limit        int       // zero means SearchUserDefaultLimit
+		Cursor       string    // NextCursor of the previous page, empty means the first page
+		SortField    string    // id (default), name or email
+		SortDesc     bool
 	}
 
 	// SearchUserResponseModel ...
 	SearchUserResponseModel struct {
-		Users []SearchUserResponseModelUser
+		Users      []SearchUserResponseModelUser
+		NextCursor string // empty when there is no next page
+		Total      int64
 	}
 
 	// SearchUserResponseModelUser ...
@@ -39,59 +60,186 @@ type (
 
 	searchUser struct {
 		userGateway igateway.User
+		emailRules  entity.EmailRules
+	}
+
+	// searchCursor is the content of the opaque cursor sent to the clients, it carries the sort so a cursor can't
+	// be used with another sort
+	searchCursor struct {
+		SortField igateway.UserSortField `json:"s"`
+		SortDesc  bool                   `json:"d,omitempty"`
+		ID        int64                  `json:"i"`
+		Value     string                 `json:"v,omitempty"`
 	}
 )
 
 // NewSearchUser ...
-func NewSearchUser(userGateway igateway.User) SearchUser {
+func NewSearchUser(userGateway igateway.User, emailRules entity.EmailRules) SearchUser {
 	return searchUser{
 		userGateway: userGateway,
+		emailRules:  emailRules,
 	}
 }
 
+// Execute finds the users that match all the informed filters, with no filter it finds all users
 func (c searchUser) Execute(ctx context.Context,
 	filter SearchUserRequestModel) (response SearchUserResponseModel, err error) {
-	if filter.Email == "" { // if email filter was not informed, find all users
-		return c.findAll(ctx)
+	gatewayFilter, err := searchFilter(filter, c.emailRules)
+	if err != nil {
+		return
 	}
 
-	return c.findByEmail(ctx, filter.Email)
-}
+	page, err := searchPage(filter)
+	if err != nil {
+		return
+	}
 
-func (c searchUser) findAll(ctx context.Context) (response SearchUserResponseModel, err error) {
-	users, err := c.userGateway.FindAll(ctx)
+	// get one more user than asked to know if there is a next page
+	limit := page.Limit
+	page.Limit++
+
+	users, err := c.userGateway.FindAll(ctx, gatewayFilter, page)
 	if err != nil {
 		err = fmt.Errorf("find all: %w", err)
 		return
 	}
 
-	response = userToResponseModel(users)
+	total, err := c.userGateway.Count(ctx, gatewayFilter)
+	if err != nil {
+		err = fmt.Errorf("count: %w", err)
+		return
+	}
+
+	if len(users) > limit {
+		users = users[:limit]
+		response.NextCursor = encodeSearchCursor(page, users[limit-1])
+	}
+
+	response.Users = userToResponseModel(users).Users
+	response.Total = total
 	return
 }
 
-func (c searchUser) findByEmail(ctx context.Context, email string) (response SearchUserResponseModel, err error) {
-	var user entity.User
-	user, err = c.userGateway.FindByEmail(ctx, email)
-	if errors.Is(err, businesserr.ErrCreateUserNotFound) {
-		err = nil
-		response.Users = make([]SearchUserResponseModelUser, 0)
+// searchFilter validates the filters and translates them to the gateway
+func searchFilter(filter SearchUserRequestModel,
+	emailRules entity.EmailRules) (gatewayFilter igateway.UserFilter, err error) {
+	if len(filter.IDs) > SearchUserMaxIDs {
+		err = businesserr.ErrSearchUserInvalidFilter
 		return
 	}
-	if err != nil {
-		err = fmt.Errorf("find by email: %w", err)
+
+	// the emails are stored in the canonical form, so the filters are compared in it too
+	email := filter.Email
+	if email != "" {
+		var ok bool
+		if email, ok = entity.CanonicalEmail(email, emailRules); !ok {
+			err = businesserr.ErrSearchUserInvalidFilter
+			return
+		}
+	}
+
+	domain := strings.TrimPrefix(filter.EmailDomain, "@")
+	if domain != "" {
+		var ok bool
+		if domain, ok = entity.CanonicalDomain(domain); !ok {
+			err = businesserr.ErrSearchUserInvalidFilter
+			return
+		}
+	}
+
+	if !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() && !filter.CreatedFrom.Before(filter.CreatedTo) {
+		err = businesserr.ErrSearchUserInvalidFilter
 		return
 	}
 
-	response = userToResponseModel([]entity.User{user})
+	gatewayFilter = igateway.UserFilter{
+		Email:        email,
+		NameContains: filter.NameContains,
+		EmailDomain:  domain,
+		IDs:          filter.IDs,
+		CreatedFrom:  filter.CreatedFrom,
+		CreatedTo:    filter.CreatedTo,
+	}
+
 	return
 }
 
+// searchPage validates the pagination of the filter and translates it to the gateway
+func searchPage(filter SearchUserRequestModel) (page igateway.UserPage, err error) {
+	if filter.Limit < 0 || filter.Limit > SearchUserMaxLimit {
+		err = businesserr.ErrSearchUserInvalidLimit
+		return
+	}
+	page.Limit = filter.Limit
+	if page.Limit == 0 {
+		page.Limit = SearchUserDefaultLimit
+	}
+
+	page.SortField = igateway.UserSortField(filter.SortField)
+	switch page.SortField {
+	case "":
+		page.SortField = igateway.UserSortByID
+	case igateway.UserSortByID, igateway.UserSortByName, igateway.UserSortByEmail:
+	default:
+		err = businesserr.ErrSearchUserInvalidSort
+		return
+	}
+	page.SortDesc = filter.SortDesc
+
+	if filter.Cursor != "" {
+		page.After, err = decodeSearchCursor(page, filter.Cursor)
+	}
+
+	return
+}
+
+func encodeSearchCursor(page igateway.UserPage, last entity.User) string {
+	cursor := searchCursor{
+		SortField: page.SortField,
+		SortDesc:  page.SortDesc,
+		ID:        last.ID,
+	}
+
+	switch page.SortField {
+	case igateway.UserSortByName:
+		cursor.Value = last.Name
+	case igateway.UserSortByEmail:
+		cursor.Value = last.Email
+	}
+
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(page igateway.UserPage, s string) (*igateway.UserCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, businesserr.ErrSearchUserInvalidCursor
+	}
+
+	var cursor searchCursor
+	if err = json.Unmarshal(b, &cursor); err != nil {
+		return nil, businesserr.ErrSearchUserInvalidCursor
+	}
+
+	// the cursor is only meaningful for the sort that generated it
+	if cursor.SortField != page.SortField || cursor.SortDesc != page.SortDesc {
+		return nil, businesserr.ErrSearchUserInvalidCursor
+	}
+
+	return &igateway.UserCursor{
+		ID:    cursor.ID,
+		Value: cursor.Value,
+	}, nil
+}
+
 func userToResponseModel(users []entity.User) (response SearchUserResponseModel) {
+	response.Users = make([]SearchUserResponseModelUser, 0, len(users))
 	for _, user := range users {
 		response.Users = append(response.Users, SearchUserResponseModelUser{
 			ID:    user.ID,
 			Name:  user.Name,
-			Email: user.Email,
+			Email: user.DisplayEmail,
 		})
 	}
 
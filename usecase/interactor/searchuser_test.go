@@ -8,9 +8,11 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/dougefr/go-clean-arch/entity"
 	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
 	"github.com/dougefr/go-clean-arch/usecase/igateway/mock_igateway"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -25,38 +27,26 @@ func TestSearchUserExecute(t *testing.T) {
 
 		userGateway := mock_igateway.NewMockUser(ctrl)
 		expectedErr := errors.New("fake-error")
-		userGateway.EXPECT().FindAll(context.Background()).Return(nil, expectedErr)
+		userGateway.EXPECT().FindAll(context.Background(), gomock.Any(), gomock.Any()).Return(nil, expectedErr)
 
-		uc := NewSearchUser(userGateway)
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
 		_, err := uc.Execute(context.Background(), SearchUserRequestModel{})
 
 		assert.True(t, errors.Is(err, expectedErr))
 	})
 
-	t.Run("should return an error if an error was returned when finding users by email", func(t *testing.T) {
-		ctrl := gomock.NewController(t)
-		defer ctrl.Finish()
-
-		userGateway := mock_igateway.NewMockUser(ctrl)
-		expectedErr := errors.New("fake-error")
-		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, expectedErr)
-
-		uc := NewSearchUser(userGateway)
-		_, err := uc.Execute(context.Background(), SearchUserRequestModel{Email: fakeEmail})
-
-		assert.True(t, errors.Is(err, expectedErr))
-	})
-
 	t.Run("should return empty result if there is no user with the email", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		userGateway := mock_igateway.NewMockUser(ctrl)
-		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().FindAll(context.Background(), igateway.UserFilter{Email: fakeEmail}, gomock.Any()).Return(nil, nil)
+		userGateway.EXPECT().Count(context.Background(), igateway.UserFilter{Email: fakeEmail}).Return(int64(0), nil)
 
-		uc := NewSearchUser(userGateway)
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
 		result, _ := uc.Execute(context.Background(), SearchUserRequestModel{Email: fakeEmail})
 
+		assert.NotNil(t, result.Users)
 		assert.Empty(t, result.Users)
 	})
 
@@ -65,20 +55,26 @@ func TestSearchUserExecute(t *testing.T) {
 		defer ctrl.Finish()
 
 		userGateway := mock_igateway.NewMockUser(ctrl)
-		userGateway.EXPECT().FindAll(context.Background()).Return([]entity.User{
+		userGateway.EXPECT().FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{
+			Limit:     SearchUserDefaultLimit + 1,
+			SortField: igateway.UserSortByID,
+		}).Return([]entity.User{
 			{
-				ID:    1,
-				Name:  "fake name 1",
-				Email: "fake1@email.com",
+				ID:           1,
+				Name:         "fake name 1",
+				Email:        "fake1@email.com",
+				DisplayEmail: "fake1@email.com",
 			},
 			{
-				ID:    2,
-				Name:  "fake name 2",
-				Email: "fake2@email.com",
+				ID:           2,
+				Name:         "fake name 2",
+				Email:        "fake2@email.com",
+				DisplayEmail: "fake2@email.com",
 			},
 		}, nil)
+		userGateway.EXPECT().Count(context.Background(), igateway.UserFilter{}).Return(int64(2), nil)
 
-		uc := NewSearchUser(userGateway)
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
 		result, _ := uc.Execute(context.Background(), SearchUserRequestModel{})
 
 		assert.Equal(t, SearchUserResponseModel{
@@ -94,22 +90,45 @@ func TestSearchUserExecute(t *testing.T) {
 					Email: "fake2@email.com",
 				},
 			},
+			Total: 2,
 		}, result)
 	})
 
-	t.Run("should return one user when an user exists with the email", func(t *testing.T) {
+	t.Run("should send all the filters to the gateway", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
+		from := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		to := time.Date(2020, 2, 1, 0, 0, 0, 0, time.UTC)
+		gatewayFilter := igateway.UserFilter{
+			Email:        fakeEmail,
+			NameContains: "fake",
+			EmailDomain:  "email.com",
+			IDs:          []int64{1, 2},
+			CreatedFrom:  from,
+			CreatedTo:    to,
+		}
+
 		userGateway := mock_igateway.NewMockUser(ctrl)
-		userGateway.EXPECT().FindByEmail(context.Background(), fakeEmail).Return(entity.User{
-			ID:    1,
-			Name:  "fake name",
-			Email: fakeEmail,
+		userGateway.EXPECT().FindAll(context.Background(), gatewayFilter, gomock.Any()).Return([]entity.User{
+			{
+				ID:           1,
+				Name:         "fake name",
+				Email:        fakeEmail,
+				DisplayEmail: fakeEmail,
+			},
 		}, nil)
+		userGateway.EXPECT().Count(context.Background(), gatewayFilter).Return(int64(1), nil)
 
-		uc := NewSearchUser(userGateway)
-		result, _ := uc.Execute(context.Background(), SearchUserRequestModel{Email: fakeEmail})
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
+		result, _ := uc.Execute(context.Background(), SearchUserRequestModel{
+			Email:        fakeEmail,
+			NameContains: "fake",
+			EmailDomain:  "@email.com",
+			IDs:          []int64{1, 2},
+			CreatedFrom:  from,
+			CreatedTo:    to,
+		})
 
 		assert.Equal(t, SearchUserResponseModel{
 			Users: []SearchUserResponseModelUser{
@@ -119,6 +138,152 @@ func TestSearchUserExecute(t *testing.T) {
 					Email: fakeEmail,
 				},
 			},
+			Total: 1,
 		}, result)
 	})
+
+	t.Run("should send the email filters in the canonical form", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		gatewayFilter := igateway.UserFilter{
+			Email:       "fake@xn--bcher-kva.example",
+			EmailDomain: "xn--bcher-kva.example",
+		}
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindAll(context.Background(), gatewayFilter, gomock.Any()).Return(nil, nil)
+		userGateway.EXPECT().Count(context.Background(), gatewayFilter).Return(int64(0), nil)
+
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), SearchUserRequestModel{
+			Email:       " Fake@Bücher.example",
+			EmailDomain: "@BÜCHER.example",
+		})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("should return an error ErrSearchUserInvalidFilter when the filters are malformed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		uc := NewSearchUser(mock_igateway.NewMockUser(ctrl), entity.EmailRules{})
+		now := time.Now()
+
+		for _, filter := range []SearchUserRequestModel{
+			{EmailDomain: "fake@email.com"},
+			{EmailDomain: "email..com"},
+			{Email: "fake email"},
+			{IDs: make([]int64, SearchUserMaxIDs+1)},
+			{CreatedFrom: now, CreatedTo: now},
+			{CreatedFrom: now, CreatedTo: now.Add(-time.Hour)},
+		} {
+			_, err := uc.Execute(context.Background(), filter)
+			assert.EqualError(t, err, businesserr.ErrSearchUserInvalidFilter.Error())
+		}
+	})
+
+	t.Run("should return an error if an error was returned when counting users", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindAll(context.Background(), gomock.Any(), gomock.Any()).Return(nil, nil)
+		userGateway.EXPECT().Count(context.Background(), igateway.UserFilter{}).Return(int64(0), expectedErr)
+
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), SearchUserRequestModel{})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return an error ErrSearchUserInvalidLimit when the limit is out of range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		uc := NewSearchUser(mock_igateway.NewMockUser(ctrl), entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), SearchUserRequestModel{Limit: SearchUserMaxLimit + 1})
+		assert.EqualError(t, err, businesserr.ErrSearchUserInvalidLimit.Error())
+
+		_, err = uc.Execute(context.Background(), SearchUserRequestModel{Limit: -1})
+		assert.EqualError(t, err, businesserr.ErrSearchUserInvalidLimit.Error())
+	})
+
+	t.Run("should return an error ErrSearchUserInvalidSort when the sort field is unknown", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		uc := NewSearchUser(mock_igateway.NewMockUser(ctrl), entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), SearchUserRequestModel{SortField: "password"})
+
+		assert.EqualError(t, err, businesserr.ErrSearchUserInvalidSort.Error())
+	})
+
+	t.Run("should return an error ErrSearchUserInvalidCursor when the cursor is malformed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		uc := NewSearchUser(mock_igateway.NewMockUser(ctrl), entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), SearchUserRequestModel{Cursor: "invalid cursor"})
+
+		assert.EqualError(t, err, businesserr.ErrSearchUserInvalidCursor.Error())
+	})
+
+	t.Run("should return a cursor to the next page that only works with the same sort", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{
+			Limit:     2,
+			SortField: igateway.UserSortByName,
+			SortDesc:  true,
+		}).Return([]entity.User{
+			{ID: 3, Name: "fake name 3"},
+			{ID: 2, Name: "fake name 2"},
+			{ID: 1, Name: "fake name 1"},
+		}, nil)
+		userGateway.EXPECT().Count(context.Background(), igateway.UserFilter{}).Return(int64(3), nil)
+
+		uc := NewSearchUser(userGateway, entity.EmailRules{})
+		result, err := uc.Execute(context.Background(), SearchUserRequestModel{
+			Limit:     1,
+			SortField: "name",
+			SortDesc:  true,
+		})
+		assert.NoError(t, err)
+		assert.Len(t, result.Users, 1)
+		assert.Equal(t, int64(3), result.Total)
+		assert.NotEmpty(t, result.NextCursor)
+
+		// the cursor points to the last user returned
+		userGateway.EXPECT().FindAll(context.Background(), igateway.UserFilter{}, igateway.UserPage{
+			Limit:     2,
+			After:     &igateway.UserCursor{ID: 3, Value: "fake name 3"},
+			SortField: igateway.UserSortByName,
+			SortDesc:  true,
+		}).Return([]entity.User{
+			{ID: 2, Name: "fake name 2"},
+		}, nil)
+		userGateway.EXPECT().Count(context.Background(), igateway.UserFilter{}).Return(int64(3), nil)
+
+		result, err = uc.Execute(context.Background(), SearchUserRequestModel{
+			Limit:     1,
+			Cursor:    result.NextCursor,
+			SortField: "name",
+			SortDesc:  true,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []SearchUserResponseModelUser{{ID: 2, Name: "fake name 2"}}, result.Users)
+		assert.Empty(t, result.NextCursor)
+
+		// but can't be used with another sort
+		_, err = uc.Execute(context.Background(), SearchUserRequestModel{
+			Cursor:    encodeSearchCursor(igateway.UserPage{SortField: igateway.UserSortByName}, entity.User{ID: 3}),
+			SortField: "email",
+		})
+		assert.EqualError(t, err, businesserr.ErrSearchUserInvalidCursor.Error())
+	})
 }
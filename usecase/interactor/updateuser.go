@@ -0,0 +1,114 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway"
+)
+
+type (
+	// UpdateUserRequestModel ...
+	UpdateUserRequestModel struct {
+		ID    int64
+		Name  *string // nil keeps the current name
+		Email *string // nil keeps the current email
+	}
+
+	// UpdateUserResponseModel ...
+	UpdateUserResponseModel struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	// UpdateUser ...
+	UpdateUser interface {
+		Execute(ctx context.Context, user UpdateUserRequestModel) (UpdateUserResponseModel, error)
+	}
+
+	updateUser struct {
+		userGateway igateway.User
+		nameRules   NameRules
+		emailRules  entity.EmailRules
+	}
+)
+
+// NewUpdateUser ...
+func NewUpdateUser(userGateway igateway.User, nameRules NameRules, emailRules entity.EmailRules) UpdateUser {
+	return updateUser{
+		userGateway: userGateway,
+		nameRules:   nameRules,
+		emailRules:  emailRules,
+	}
+}
+
+// Execute ...
+func (c updateUser) Execute(ctx context.Context,
+	user UpdateUserRequestModel) (response UpdateUserResponseModel, err error) {
+	// Get the current state of the user
+	current, err := c.userGateway.FindByID(ctx, user.ID)
+	if errors.Is(err, businesserr.ErrCreateUserNotFound) {
+		err = businesserr.ErrUpdateUserNotFound
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("find by id: %w", err)
+		return
+	}
+
+	// Static validations, of the informed fields only
+	name, canonicalEmail, err := validateUser(c.nameRules, c.emailRules, user.Name, user.Email)
+	if err != nil {
+		return
+	}
+
+	// Apply only the informed fields
+	updated := current
+	if user.Name != nil {
+		updated.Name = name
+	}
+	if user.Email != nil {
+		updated.Email = canonicalEmail
+		updated.DisplayEmail = strings.TrimSpace(*user.Email)
+	}
+
+	// Check if another user exists with the new email, a change of the display form only is not a new email
+	if updated.Email != current.Email {
+		if _, err = c.userGateway.FindByEmail(ctx, updated.Email); err != nil &&
+			!errors.Is(err, businesserr.ErrCreateUserNotFound) {
+			err = fmt.Errorf("find by email: %w", err)
+			return
+		}
+		if err == nil {
+			err = businesserr.ErrUpdateUserAlreadyExists
+			return
+		}
+	}
+
+	// Update the user
+	userUpdated, err := c.userGateway.Update(ctx, updated)
+	if errors.Is(err, businesserr.ErrCreateUserAlreadyExists) {
+		// the email was taken by a concurrent request after the check above
+		err = businesserr.Wrap(businesserr.ErrUpdateUserAlreadyExists, err, nil)
+		return
+	}
+	if err != nil {
+		err = fmt.Errorf("update user: %w", err)
+		return
+	}
+
+	response.ID = userUpdated.ID
+	response.Name = userUpdated.Name
+	response.Email = userUpdated.DisplayEmail
+
+	return
+}
@@ -0,0 +1,271 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/dougefr/go-clean-arch/usecase/igateway/mock_igateway"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateUserExecute(t *testing.T) {
+	const fakeID = int64(1)
+	const fakeEmail = "fake@email.com"
+	const fakeName = "fake name"
+	newEmail := "new@email.com"
+	newName := "new name"
+	emptyString := ""
+	currentUser := entity.User{
+		ID:           fakeID,
+		Name:         fakeName,
+		Email:        fakeEmail,
+		DisplayEmail: fakeEmail,
+	}
+
+	t.Run("should return an error ErrUpdateUserNotFound when there is no user with the ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:   fakeID,
+			Name: &newName,
+		})
+
+		assert.EqualError(t, err, businesserr.ErrUpdateUserNotFound.Error())
+	})
+
+	t.Run("should return an unknown error when occur an error when finding the user by ID", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(entity.User{}, expectedErr)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:   fakeID,
+			Name: &newName,
+		})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return a validation error when user name is empty", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:   fakeID,
+			Name: &emptyString,
+		})
+
+		assert.Equal(t, businesserr.ValidationError{Violations: []businesserr.FieldViolation{
+			{Field: "name", Code: businesserr.ViolationRequired, Message: "user name cannot be empty"},
+		}}, err)
+	})
+
+	t.Run("should return a validation error when user email is empty", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:    fakeID,
+			Email: &emptyString,
+		})
+
+		assert.Equal(t, businesserr.ValidationError{Violations: []businesserr.FieldViolation{
+			{Field: "email", Code: businesserr.ViolationRequired, Message: "user email cannot be empty"},
+		}}, err)
+	})
+
+	t.Run("should return an unknown error when occur an error when checking if there is no user with the new email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), newEmail).Return(entity.User{}, expectedErr)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:    fakeID,
+			Email: &newEmail,
+		})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should return an error ErrUpdateUserAlreadyExists when there is another user using the new email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), newEmail).Return(entity.User{ID: 2}, nil)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:    fakeID,
+			Email: &newEmail,
+		})
+
+		assert.EqualError(t, err, businesserr.ErrUpdateUserAlreadyExists.Error())
+	})
+
+	t.Run("should return an error ErrUpdateUserAlreadyExists when the email was taken while updating the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), newEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Update(context.Background(), gomock.Any()).Return(entity.User{}, businesserr.ErrCreateUserAlreadyExists)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:    fakeID,
+			Email: &newEmail,
+		})
+
+		assert.True(t, errors.Is(err, businesserr.ErrUpdateUserAlreadyExists))
+		assert.True(t, errors.Is(err, businesserr.ErrCreateUserAlreadyExists))
+	})
+
+	t.Run("should return an unknown error when occur an error when updating the user", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		expectedErr := errors.New("fake-error")
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().Update(context.Background(), entity.User{
+			ID:           fakeID,
+			Name:         newName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeEmail,
+		}).Return(entity.User{}, expectedErr)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		_, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:   fakeID,
+			Name: &newName,
+		})
+
+		assert.True(t, errors.Is(err, expectedErr))
+	})
+
+	t.Run("should keep the fields that were not informed when everything goes fine", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().Update(context.Background(), entity.User{
+			ID:           fakeID,
+			Name:         newName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeEmail,
+		}).Return(entity.User{
+			ID:           fakeID,
+			Name:         newName,
+			Email:        fakeEmail,
+			DisplayEmail: fakeEmail,
+		}, nil)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		responseModel, _ := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:   fakeID,
+			Name: &newName,
+		})
+
+		assert.Equal(t, UpdateUserResponseModel{
+			ID:    fakeID,
+			Name:  newName,
+			Email: fakeEmail,
+		}, responseModel)
+	})
+
+	t.Run("should not check the email of the other users when only its display form changes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		displayEmail := " Fake@Email.com"
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().Update(context.Background(), entity.User{
+			ID:           fakeID,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: "Fake@Email.com",
+		}).Return(entity.User{
+			ID:           fakeID,
+			Name:         fakeName,
+			Email:        fakeEmail,
+			DisplayEmail: "Fake@Email.com",
+		}, nil)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		responseModel, err := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:    fakeID,
+			Email: &displayEmail,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Fake@Email.com", responseModel.Email)
+	})
+
+	t.Run("should return the user updated data when every field was informed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		userGateway := mock_igateway.NewMockUser(ctrl)
+		userGateway.EXPECT().FindByID(context.Background(), fakeID).Return(currentUser, nil)
+		userGateway.EXPECT().FindByEmail(context.Background(), newEmail).Return(entity.User{}, businesserr.ErrCreateUserNotFound)
+		userGateway.EXPECT().Update(context.Background(), entity.User{
+			ID:           fakeID,
+			Name:         newName,
+			Email:        newEmail,
+			DisplayEmail: newEmail,
+		}).Return(entity.User{
+			ID:           fakeID,
+			Name:         newName,
+			Email:        newEmail,
+			DisplayEmail: newEmail,
+		}, nil)
+
+		uc := NewUpdateUser(userGateway, DefaultNameRules, entity.EmailRules{})
+		responseModel, _ := uc.Execute(context.Background(), UpdateUserRequestModel{
+			ID:    fakeID,
+			Name:  &newName,
+			Email: &newEmail,
+		})
+
+		assert.Equal(t, UpdateUserResponseModel{
+			ID:    fakeID,
+			Name:  newName,
+			Email: newEmail,
+		}, responseModel)
+	})
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dougefr/go-clean-arch/entity"
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"golang.org/x/text/unicode/norm"
+)
+
+// nameMaxBytesPerChar bounds the size of a name before its normalization, a char with some combining
+// marks and the spaces around the name fit in it
+const nameMaxBytesPerChar = 4 * utf8.UTFMax
+
+// NameRules are the rules of the user names. The names are trimmed and NFC normalized before being
+// checked, so the lengths are of the stored form
+type NameRules struct {
+	MinLength int                   // in runes, zero means no min
+	MaxLength int                   // in runes, zero means no max
+	Forbidden []*unicode.RangeTable // chars that can't be anywhere in the name
+}
+
+// DefaultNameRules refuse the control, format (ex: bidi overrides), private use, surrogate and line
+// separator chars, which are invisible or change how the text around them is shown
+var DefaultNameRules = NameRules{
+	MinLength: 1,
+	MaxLength: 100,
+	Forbidden: []*unicode.RangeTable{unicode.Cc, unicode.Cf, unicode.Co, unicode.Cs, unicode.Zl, unicode.Zp},
+}
+
+// validateUser checks the user data against the rules shared by the creation and the update,
+// returning a businesserr.ValidationError with every field that breaks them. The fields are returned
+// in the form they must be stored, the nil ones aren't validated since the update keeps the current ones
+func validateUser(rules NameRules, emailRules entity.EmailRules, name, email *string) (validName, canonicalEmail string, err error) {
+	var violations businesserr.Violations
+
+	if name != nil {
+		validName = rules.validate(*name, &violations)
+	}
+
+	if email != nil {
+		if strings.TrimSpace(*email) == "" {
+			violations.Add("email", businesserr.ViolationRequired, "user email cannot be empty", nil)
+		} else if canonical, ok := entity.CanonicalEmail(*email, emailRules); !ok {
+			violations.Add("email", businesserr.ViolationInvalidFormat, "user email is not a valid address", nil)
+		} else {
+			canonicalEmail = canonical
+		}
+	}
+
+	return validName, canonicalEmail, violations.Err()
+}
+
+// validate adds the violations of the name, returning its normalized form
+func (r NameRules) validate(name string, violations *businesserr.Violations) string {
+	// a huge name isn't normalized, no normalization would make it short enough
+	if r.MaxLength > 0 && len(name) > r.MaxLength*nameMaxBytesPerChar {
+		violations.Add("name", businesserr.ViolationTooLong,
+			fmt.Sprintf("user name must have at most %d characters", r.MaxLength),
+			map[string]interface{}{"max": r.MaxLength})
+		return ""
+	}
+
+	name = strings.TrimSpace(norm.NFC.String(name))
+	length := utf8.RuneCountInString(name)
+
+	switch {
+	case name == "":
+		violations.Add("name", businesserr.ViolationRequired, "user name cannot be empty", nil)
+	case length < r.MinLength:
+		violations.Add("name", businesserr.ViolationTooShort,
+			fmt.Sprintf("user name must have at least %d characters", r.MinLength),
+			map[string]interface{}{"min": r.MinLength})
+	case r.MaxLength > 0 && length > r.MaxLength:
+		violations.Add("name", businesserr.ViolationTooLong,
+			fmt.Sprintf("user name must have at most %d characters", r.MaxLength),
+			map[string]interface{}{"max": r.MaxLength})
+	}
+
+	// the chars are reported even when the length is wrong, so both can be fixed at once. The invalid
+	// UTF-8 bytes are read as utf8.RuneError
+	for _, c := range name {
+		if c == utf8.RuneError || unicode.IsOneOf(r.Forbidden, c) {
+			violations.Add("name", businesserr.ViolationForbiddenCharacter,
+				fmt.Sprintf("user name cannot have the character %U", c),
+				map[string]interface{}{"char": fmt.Sprintf("%U", c)})
+			break
+		}
+	}
+
+	return name
+}
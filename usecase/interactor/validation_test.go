@@ -0,0 +1,102 @@
+// Copyright (c) 2020. Douglas Rodrigues - All rights reserved.
+// This file is licensed under the MIT License.
+// License text available at https://opensource.org/licenses/MIT
+
+package interactor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dougefr/go-clean-arch/usecase/businesserr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameRules(t *testing.T) {
+	rules := NameRules{MinLength: 3, MaxLength: 5, Forbidden: DefaultNameRules.Forbidden}
+
+	validate := func(name string) (string, businesserr.Violations) {
+		var violations businesserr.Violations
+		return rules.validate(name, &violations), violations
+	}
+
+	t.Run("should trim and normalize the name to NFC", func(t *testing.T) {
+		name, violations := validate(" \tJose\u0301 ")
+
+		assert.Empty(t, violations)
+		assert.Equal(t, "Jos\u00e9", name)
+	})
+
+	t.Run("should refuse a name of spaces only as empty", func(t *testing.T) {
+		_, violations := validate("   ")
+
+		assert.Equal(t, businesserr.Violations{
+			{Field: "name", Code: businesserr.ViolationRequired, Message: "user name cannot be empty"},
+		}, violations)
+	})
+
+	t.Run("should count the length in characters of the normalized name", func(t *testing.T) {
+		_, violations := validate("Jo")
+		assert.Equal(t, businesserr.Violations{{
+			Field:   "name",
+			Code:    businesserr.ViolationTooShort,
+			Message: "user name must have at least 3 characters",
+			Params:  map[string]interface{}{"min": 3},
+		}}, violations)
+
+		_, violations = validate("Jose\u0301e")
+		assert.Empty(t, violations)
+
+		_, violations = validate("Jose\u0301ee")
+		assert.Equal(t, businesserr.Violations{{
+			Field:   "name",
+			Code:    businesserr.ViolationTooLong,
+			Message: "user name must have at most 5 characters",
+			Params:  map[string]interface{}{"max": 5},
+		}}, violations)
+	})
+
+	t.Run("should refuse a huge name without normalizing it", func(t *testing.T) {
+		name, violations := validate(strings.Repeat("a", 10<<20))
+
+		assert.Empty(t, name)
+		assert.Len(t, violations, 1)
+		assert.Equal(t, businesserr.ViolationTooLong, violations[0].Code)
+	})
+
+	t.Run("should refuse the forbidden characters", func(t *testing.T) {
+		for name, char := range map[string]string{
+			"Jo\x00e":      "U+0000",
+			"Jo\ne":        "U+000A",
+			"Jo\u202Eeo":   "U+202E",
+			"Jo\u2028e":    "U+2028",
+			"Jo\xffe":      "U+FFFD",
+			"J\u200Boe":    "U+200B",
+			"J\uE000oe":    "U+E000",
+			"J\u200B\x00e": "U+200B",
+		} {
+			_, violations := validate(name)
+
+			assert.Equal(t, businesserr.Violations{{
+				Field:   "name",
+				Code:    businesserr.ViolationForbiddenCharacter,
+				Message: "user name cannot have the character " + char,
+				Params:  map[string]interface{}{"char": char},
+			}}, violations, name)
+		}
+	})
+
+	t.Run("should report the length and the forbidden characters at once", func(t *testing.T) {
+		_, violations := validate("J\x00")
+
+		assert.Len(t, violations, 2)
+	})
+
+	t.Run("should have no limits when they are zero", func(t *testing.T) {
+		var violations businesserr.Violations
+		name := NameRules{}.validate(strings.Repeat("a", 1000)+"\x00", &violations)
+
+		assert.Empty(t, violations)
+		assert.Len(t, name, 1001)
+	})
+}